@@ -1,8 +1,14 @@
 package consts
 
 const (
-	OrderPaymentStatusUnpaid = "UNPAID"
-	OrderPaymentStatusPaid   = "PAID"
+	OrderPaymentStatusUnpaid        = "UNPAID"
+	OrderPaymentStatusPaid          = "PAID"
+	OrderPaymentStatusFailed        = "FAILED"
+	OrderPaymentStatusCancelled     = "CANCELLED"
+	OrderPaymentStatusExpired       = "EXPIRED"
+	OrderPaymentStatusRefunded      = "REFUNDED"
+	OrderPaymentStatusPartialRefund = "PARTIAL_REFUND"
+	OrderPaymentStatusRefundDue     = "REFUND_DUE" // Dibayar setelah order dibatalkan, dana harus dikembalikan
 )
 
 const (
@@ -12,8 +18,20 @@ const (
 	OrderStatusCancelled = 3
 )
 
+// Status transaksi yang dikirim Midtrans melalui notifikasi dan status API
 const (
-	PaymentStatusCapture    = "capture"
-	FraudStatusAccept       = "accept"
-	PaymentStatusSettlement = "settlement"
+	PaymentStatusCapture       = "capture"
+	PaymentStatusSettlement    = "settlement"
+	PaymentStatusPending       = "pending"
+	PaymentStatusDeny          = "deny"
+	PaymentStatusCancel        = "cancel"
+	PaymentStatusExpire        = "expire"
+	PaymentStatusRefund        = "refund"
+	PaymentStatusPartialRefund = "partial_refund"
+)
+
+const (
+	FraudStatusAccept    = "accept"
+	FraudStatusChallenge = "challenge"
+	FraudStatusDeny      = "deny"
 )
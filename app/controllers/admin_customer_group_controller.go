@@ -0,0 +1,286 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/gosimple/slug"
+	"github.com/shopspring/decimal"
+	"github.com/unrolled/render"
+	"gorm.io/gorm"
+
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/core/session/flash"
+	"github.com/gieart87/gotoko/app/models"
+)
+
+// AdminCustomerGroups menampilkan daftar kelompok pelanggan
+func (server *Server) AdminCustomerGroups(w http.ResponseWriter, r *http.Request) {
+	render := render.New(render.Options{
+		Layout:     "layout",
+		Extensions: []string{".html", ".tmpl"},
+	})
+
+	var groupModel models.CustomerGroup
+	groups, err := groupModel.GetCustomerGroups(server.DB)
+	if err != nil {
+		http.Error(w, "Gagal mengambil kelompok pelanggan", http.StatusInternalServerError)
+		return
+	}
+
+	var roleModel models.Role
+	roles, _ := roleModel.GetRoles(server.DB)
+
+	// Nama role yang memakai kelompok sebagai kelompok bawaan, per ID kelompok
+	groupRoles := map[string][]string{}
+	for _, role := range roles {
+		groupRoles[role.CustomerGroupID] = append(groupRoles[role.CustomerGroupID], role.Name)
+	}
+
+	_ = render.HTML(w, http.StatusOK, "admin_customer_groups", map[string]interface{}{
+		"groups":     groups,
+		"groupRoles": groupRoles,
+		"success":    flash.GetFlash(w, r, "success"),
+		"error":      flash.GetFlash(w, r, "error"),
+		"user":       auth.CurrentUser(server.DB, w, r),
+	})
+}
+
+// AdminNewCustomerGroup menampilkan form kelompok pelanggan baru
+func (server *Server) AdminNewCustomerGroup(w http.ResponseWriter, r *http.Request) {
+	server.renderCustomerGroupForm(w, r, &models.CustomerGroup{PriceColumn: models.CustomerGroupPriceRetail}, nil, nil)
+}
+
+// AdminCreateCustomerGroup menyimpan kelompok pelanggan baru beserta role yang memakainya
+func (server *Server) AdminCreateCustomerGroup(w http.ResponseWriter, r *http.Request) {
+	group := &models.CustomerGroup{}
+	err := fillCustomerGroup(group, r)
+	roleIDs := r.Form["role_ids"]
+	if err == nil {
+		err = server.saveCustomerGroup(group, roleIDs)
+	}
+	if err != nil {
+		server.renderCustomerGroupForm(w, r, group, roleIDs, err)
+		return
+	}
+
+	flash.SetFlash(w, r, "success", "Kelompok pelanggan berhasil disimpan")
+	http.Redirect(w, r, "/admin/customer-groups", http.StatusSeeOther)
+}
+
+// AdminEditCustomerGroup menampilkan form ubah kelompok pelanggan
+func (server *Server) AdminEditCustomerGroup(w http.ResponseWriter, r *http.Request) {
+	var groupModel models.CustomerGroup
+	group, err := groupModel.FindByID(server.DB, mux.Vars(r)["id"])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var roleIDs []string
+	server.DB.Model(&models.Role{}).Where("customer_group_id = ?", group.ID).Pluck("id", &roleIDs)
+
+	server.renderCustomerGroupForm(w, r, group, roleIDs, nil)
+}
+
+// AdminUpdateCustomerGroup menyimpan perubahan kelompok pelanggan
+func (server *Server) AdminUpdateCustomerGroup(w http.ResponseWriter, r *http.Request) {
+	var groupModel models.CustomerGroup
+	group, err := groupModel.FindByID(server.DB, mux.Vars(r)["id"])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	err = fillCustomerGroup(group, r)
+	roleIDs := r.Form["role_ids"]
+	if err == nil {
+		err = server.saveCustomerGroup(group, roleIDs)
+	}
+	if err != nil {
+		server.renderCustomerGroupForm(w, r, group, roleIDs, err)
+		return
+	}
+
+	flash.SetFlash(w, r, "success", "Kelompok pelanggan berhasil diubah")
+	http.Redirect(w, r, "/admin/customer-groups", http.StatusSeeOther)
+}
+
+// AdminDeleteCustomerGroup menghapus kelompok pelanggan. Pengguna dan role yang memakainya kembali ke harga eceran.
+func (server *Server) AdminDeleteCustomerGroup(w http.ResponseWriter, r *http.Request) {
+	var groupModel models.CustomerGroup
+	group, err := groupModel.FindByID(server.DB, mux.Vars(r)["id"])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Aturan harga tanpa kelompok berlaku untuk semua pelanggan, jadi aturannya harus diubah atau dihapus lebih dulu
+	var ruleCount int64
+	server.DB.Model(&models.PriceRule{}).Where("customer_group_id = ?", group.ID).Count(&ruleCount)
+	if ruleCount > 0 {
+		flash.SetFlash(w, r, "error", "Kelompok "+group.Name+" masih dipakai aturan harga")
+		http.Redirect(w, r, "/admin/customer-groups", http.StatusSeeOther)
+		return
+	}
+
+	err = server.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("customer_group_id = ?", group.ID).Update("customer_group_id", "").Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Role{}).Where("customer_group_id = ?", group.ID).Update("customer_group_id", "").Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(group).Error
+	})
+	if err != nil {
+		flash.SetFlash(w, r, "error", "Gagal menghapus kelompok pelanggan")
+	} else {
+		flash.SetFlash(w, r, "success", "Kelompok pelanggan berhasil dihapus")
+	}
+
+	http.Redirect(w, r, "/admin/customer-groups", http.StatusSeeOther)
+}
+
+func (server *Server) renderCustomerGroupForm(w http.ResponseWriter, r *http.Request, group *models.CustomerGroup, roleIDs []string, formErr error) {
+	render := render.New(render.Options{
+		Layout:     "layout",
+		Extensions: []string{".html", ".tmpl"},
+	})
+
+	var roleModel models.Role
+	roles, _ := roleModel.GetRoles(server.DB)
+
+	selectedRoles := map[string]bool{}
+	for _, roleID := range roleIDs {
+		selectedRoles[roleID] = true
+	}
+
+	status := http.StatusOK
+	data := map[string]interface{}{
+		"group":         group,
+		"priceColumns":  models.CustomerGroupPriceColumns,
+		"roles":         roles,
+		"selectedRoles": selectedRoles,
+		"user":          auth.CurrentUser(server.DB, w, r),
+	}
+	if formErr != nil {
+		status = http.StatusUnprocessableEntity
+		data["error"] = []string{formErr.Error()}
+	}
+
+	_ = render.HTML(w, status, "admin_customer_group_form", data)
+}
+
+// fillCustomerGroup mengisi kelompok pelanggan dari input form lalu memvalidasinya
+func fillCustomerGroup(group *models.CustomerGroup, r *http.Request) error {
+	group.Name = strings.TrimSpace(r.FormValue("name"))
+	group.Code = slug.Make(r.FormValue("code"))
+	if group.Code == "" {
+		group.Code = slug.Make(group.Name)
+	}
+	group.Description = strings.TrimSpace(r.FormValue("description"))
+	group.PriceColumn = r.FormValue("price_column")
+
+	group.DiscountPercent = decimal.Zero
+	if value := strings.TrimSpace(r.FormValue("discount_percent")); value != "" {
+		var err error
+		group.DiscountPercent, err = decimal.NewFromString(value)
+		if err != nil {
+			return errors.New("Potongan persen harus berupa angka")
+		}
+	}
+
+	return group.Validate()
+}
+
+// saveCustomerGroup menyimpan kelompok pelanggan dan menjadikannya kelompok bawaan untuk role terpilih.
+// Role yang tidak lagi dipilih dilepas dari kelompok ini.
+func (server *Server) saveCustomerGroup(group *models.CustomerGroup, roleIDs []string) error {
+	return server.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if group.ID == "" {
+			err = tx.Create(group).Error
+		} else {
+			err = tx.Save(group).Error
+		}
+		if err != nil {
+			return errors.New("Gagal menyimpan kelompok, pastikan kode belum dipakai kelompok lain")
+		}
+
+		err = tx.Model(&models.Role{}).Where("customer_group_id = ?", group.ID).Update("customer_group_id", "").Error
+		if err != nil {
+			return err
+		}
+		if len(roleIDs) == 0 {
+			return nil
+		}
+
+		return tx.Model(&models.Role{}).Where("id IN ?", roleIDs).Update("customer_group_id", group.ID).Error
+	})
+}
+
+// AdminCustomers menampilkan daftar pengguna beserta kelompok pelanggannya
+func (server *Server) AdminCustomers(w http.ResponseWriter, r *http.Request) {
+	render := render.New(render.Options{
+		Layout:     "layout",
+		Extensions: []string{".html", ".tmpl"},
+	})
+
+	var userModel models.User
+	users, err := userModel.GetUsers(server.DB)
+	if err != nil {
+		http.Error(w, "Gagal mengambil data pengguna", http.StatusInternalServerError)
+		return
+	}
+
+	var groupModel models.CustomerGroup
+	groups, _ := groupModel.GetCustomerGroups(server.DB)
+
+	groupNames := map[string]string{}
+	for _, group := range groups {
+		groupNames[group.ID] = group.Name
+	}
+
+	_ = render.HTML(w, http.StatusOK, "admin_customers", map[string]interface{}{
+		"customers":  users,
+		"groups":     groups,
+		"groupNames": groupNames,
+		"success":    flash.GetFlash(w, r, "success"),
+		"error":      flash.GetFlash(w, r, "error"),
+		"user":       auth.CurrentUser(server.DB, w, r),
+	})
+}
+
+// AdminUpdateCustomerGroupOfUser mengubah kelompok pelanggan seorang pengguna. Pilihan kosong berarti
+// pengguna mengikuti kelompok bawaan role-nya.
+func (server *Server) AdminUpdateCustomerGroupOfUser(w http.ResponseWriter, r *http.Request) {
+	var userModel models.User
+	customer, err := userModel.FindByID(server.DB, mux.Vars(r)["id"])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	groupID := r.FormValue("customer_group_id")
+	if groupID != "" {
+		var groupModel models.CustomerGroup
+		if _, err := groupModel.FindByID(server.DB, groupID); err != nil {
+			flash.SetFlash(w, r, "error", "Kelompok pelanggan tidak ditemukan")
+			http.Redirect(w, r, "/admin/customers", http.StatusSeeOther)
+			return
+		}
+	}
+
+	err = server.DB.Model(customer).Update("customer_group_id", groupID).Error
+	if err != nil {
+		flash.SetFlash(w, r, "error", "Gagal mengubah kelompok pelanggan")
+	} else {
+		flash.SetFlash(w, r, "success", "Kelompok pelanggan "+customer.FirstName+" berhasil diubah")
+	}
+
+	http.Redirect(w, r, "/admin/customers", http.StatusSeeOther)
+}
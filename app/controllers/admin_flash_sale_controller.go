@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/unrolled/render"
+
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/core/session/flash"
+	"github.com/gieart87/gotoko/app/models"
+)
+
+// AdminFlashSales menampilkan daftar flash sale beserta jumlah terjualnya
+func (server *Server) AdminFlashSales(w http.ResponseWriter, r *http.Request) {
+	render := render.New(render.Options{
+		Layout:     "layout",
+		Extensions: []string{".html", ".tmpl"},
+	})
+
+	var saleModel models.FlashSale
+	sales, err := saleModel.GetFlashSales(server.DB)
+	if err != nil {
+		http.Error(w, "Gagal mengambil flash sale", http.StatusInternalServerError)
+		return
+	}
+
+	_ = render.HTML(w, http.StatusOK, "admin_flash_sales", map[string]interface{}{
+		"sales":   sales,
+		"user":    auth.CurrentUser(server.DB, w, r),
+		"success": flash.GetFlash(w, r, "success"),
+		"error":   flash.GetFlash(w, r, "error"),
+	})
+}
+
+// AdminNewFlashSale menampilkan form flash sale baru
+func (server *Server) AdminNewFlashSale(w http.ResponseWriter, r *http.Request) {
+	server.renderFlashSaleForm(w, r, &models.FlashSale{Status: 1}, nil)
+}
+
+// AdminCreateFlashSale menyimpan flash sale baru
+func (server *Server) AdminCreateFlashSale(w http.ResponseWriter, r *http.Request) {
+	sale := &models.FlashSale{}
+	err := server.fillFlashSale(sale, r)
+	if err != nil {
+		server.renderFlashSaleForm(w, r, sale, err)
+		return
+	}
+
+	err = server.DB.Create(sale).Error
+	if err != nil {
+		server.renderFlashSaleForm(w, r, sale, errors.New("Gagal menyimpan flash sale"))
+		return
+	}
+
+	flash.SetFlash(w, r, "success", "Flash sale berhasil disimpan")
+	http.Redirect(w, r, "/admin/flash-sales", http.StatusSeeOther)
+}
+
+// AdminEditFlashSale menampilkan form ubah flash sale
+func (server *Server) AdminEditFlashSale(w http.ResponseWriter, r *http.Request) {
+	var saleModel models.FlashSale
+	sale, err := saleModel.FindByID(server.DB, mux.Vars(r)["id"])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	server.renderFlashSaleForm(w, r, sale, nil)
+}
+
+// AdminUpdateFlashSale menyimpan perubahan flash sale
+func (server *Server) AdminUpdateFlashSale(w http.ResponseWriter, r *http.Request) {
+	var saleModel models.FlashSale
+	sale, err := saleModel.FindByID(server.DB, mux.Vars(r)["id"])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	err = server.fillFlashSale(sale, r)
+	if err != nil {
+		server.renderFlashSaleForm(w, r, sale, err)
+		return
+	}
+
+	err = server.DB.Save(sale).Error
+	if err != nil {
+		server.renderFlashSaleForm(w, r, sale, errors.New("Gagal menyimpan flash sale"))
+		return
+	}
+
+	flash.SetFlash(w, r, "success", "Flash sale berhasil diubah")
+	http.Redirect(w, r, "/admin/flash-sales", http.StatusSeeOther)
+}
+
+// AdminDeleteFlashSale menghapus flash sale. Item keranjang yang memakai harganya kembali ke harga
+// normal saat keranjang dihitung ulang atau saat checkout.
+func (server *Server) AdminDeleteFlashSale(w http.ResponseWriter, r *http.Request) {
+	var saleModel models.FlashSale
+	sale, err := saleModel.FindByID(server.DB, mux.Vars(r)["id"])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	err = server.DB.Delete(sale).Error
+	if err != nil {
+		flash.SetFlash(w, r, "error", "Gagal menghapus flash sale")
+	} else {
+		flash.SetFlash(w, r, "success", "Flash sale berhasil dihapus")
+	}
+
+	http.Redirect(w, r, "/admin/flash-sales", http.StatusSeeOther)
+}
+
+func (server *Server) renderFlashSaleForm(w http.ResponseWriter, r *http.Request, sale *models.FlashSale, formErr error) {
+	render := render.New(render.Options{
+		Layout:     "layout",
+		Extensions: []string{".html", ".tmpl"},
+	})
+
+	status := http.StatusOK
+	data := map[string]interface{}{
+		"sale": sale,
+		"user": auth.CurrentUser(server.DB, w, r),
+	}
+	if formErr != nil {
+		status = http.StatusUnprocessableEntity
+		data["error"] = []string{formErr.Error()}
+	}
+
+	_ = render.HTML(w, status, "admin_flash_sale_form", data)
+}
+
+// fillFlashSale mengisi flash sale dari input form lalu memvalidasinya
+func (server *Server) fillFlashSale(sale *models.FlashSale, r *http.Request) error {
+	sale.Name = strings.TrimSpace(r.FormValue("name"))
+	sale.ProductID = strings.TrimSpace(r.FormValue("product_id"))
+	sale.UnitName = strings.TrimSpace(r.FormValue("unit_name"))
+
+	sale.Status = 0
+	if r.FormValue("status") == "1" {
+		sale.Status = 1
+	}
+
+	var err error
+	sale.Price, err = parseMoneyField(r.FormValue("price"))
+	if err != nil {
+		return errors.New("Harga flash sale harus berupa angka")
+	}
+
+	sale.Quota, err = parseLimitField(r.FormValue("quota"))
+	if err != nil {
+		return errors.New("Kuota harus berupa angka")
+	}
+
+	startsAt, err := parsePriceRuleTime(r.FormValue("starts_at"))
+	if err != nil {
+		return errors.New("Format waktu mulai tidak valid")
+	}
+	sale.StartsAt = startsAt.Time
+
+	endsAt, err := parsePriceRuleTime(r.FormValue("ends_at"))
+	if err != nil {
+		return errors.New("Format waktu selesai tidak valid")
+	}
+	sale.EndsAt = endsAt.Time
+
+	if err := sale.Validate(); err != nil {
+		return err
+	}
+
+	// Flash sale harus menunjuk satuan yang benar-benar dijual pada produk tersebut
+	productModel := models.Product{}
+	product, err := productModel.FindByID(server.DB, sale.ProductID)
+	if err != nil {
+		return errors.New("Produk " + sale.ProductID + " tidak ditemukan")
+	}
+	if _, ok := product.FindUnit(sale.UnitName); !ok {
+		return errors.New("Produk " + product.Name + " tidak memiliki satuan " + sale.UnitName)
+	}
+
+	return nil
+}
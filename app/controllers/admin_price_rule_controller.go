@@ -0,0 +1,231 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+	"github.com/unrolled/render"
+
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/core/session/flash"
+	"github.com/gieart87/gotoko/app/models"
+)
+
+// Format input datetime-local pada form aturan harga
+const priceRuleTimeLayout = "2006-01-02T15:04"
+
+// AdminPriceRules menampilkan daftar aturan harga
+func (server *Server) AdminPriceRules(w http.ResponseWriter, r *http.Request) {
+	render := render.New(render.Options{
+		Layout:     "layout",
+		Extensions: []string{".html", ".tmpl"},
+	})
+
+	var ruleModel models.PriceRule
+	rules, err := ruleModel.GetPriceRules(server.DB)
+	if err != nil {
+		http.Error(w, "Gagal mengambil aturan harga", http.StatusInternalServerError)
+		return
+	}
+
+	data := server.priceRuleFormData(w, r)
+	data["rules"] = rules
+	data["success"] = flash.GetFlash(w, r, "success")
+	data["error"] = flash.GetFlash(w, r, "error")
+
+	_ = render.HTML(w, http.StatusOK, "admin_price_rules", data)
+}
+
+// AdminNewPriceRule menampilkan form aturan harga baru
+func (server *Server) AdminNewPriceRule(w http.ResponseWriter, r *http.Request) {
+	server.renderPriceRuleForm(w, r, &models.PriceRule{MinQty: 1, Type: models.PriceRuleTypePercent, Status: 1}, nil)
+}
+
+// AdminCreatePriceRule menyimpan aturan harga baru
+func (server *Server) AdminCreatePriceRule(w http.ResponseWriter, r *http.Request) {
+	rule := &models.PriceRule{}
+	err := server.fillPriceRule(rule, r)
+	if err != nil {
+		server.renderPriceRuleForm(w, r, rule, err)
+		return
+	}
+
+	err = server.DB.Create(rule).Error
+	if err != nil {
+		server.renderPriceRuleForm(w, r, rule, err)
+		return
+	}
+
+	flash.SetFlash(w, r, "success", "Aturan harga berhasil disimpan")
+	http.Redirect(w, r, "/admin/price-rules", http.StatusSeeOther)
+}
+
+// AdminEditPriceRule menampilkan form ubah aturan harga
+func (server *Server) AdminEditPriceRule(w http.ResponseWriter, r *http.Request) {
+	var ruleModel models.PriceRule
+	rule, err := ruleModel.FindByID(server.DB, mux.Vars(r)["id"])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	server.renderPriceRuleForm(w, r, rule, nil)
+}
+
+// AdminUpdatePriceRule menyimpan perubahan aturan harga
+func (server *Server) AdminUpdatePriceRule(w http.ResponseWriter, r *http.Request) {
+	var ruleModel models.PriceRule
+	rule, err := ruleModel.FindByID(server.DB, mux.Vars(r)["id"])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	err = server.fillPriceRule(rule, r)
+	if err != nil {
+		server.renderPriceRuleForm(w, r, rule, err)
+		return
+	}
+
+	err = server.DB.Save(rule).Error
+	if err != nil {
+		server.renderPriceRuleForm(w, r, rule, err)
+		return
+	}
+
+	flash.SetFlash(w, r, "success", "Aturan harga berhasil diubah")
+	http.Redirect(w, r, "/admin/price-rules", http.StatusSeeOther)
+}
+
+// AdminDeletePriceRule menghapus aturan harga
+func (server *Server) AdminDeletePriceRule(w http.ResponseWriter, r *http.Request) {
+	var ruleModel models.PriceRule
+	rule, err := ruleModel.FindByID(server.DB, mux.Vars(r)["id"])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	err = server.DB.Delete(rule).Error
+	if err != nil {
+		flash.SetFlash(w, r, "error", "Gagal menghapus aturan harga")
+	} else {
+		flash.SetFlash(w, r, "success", "Aturan harga berhasil dihapus")
+	}
+
+	http.Redirect(w, r, "/admin/price-rules", http.StatusSeeOther)
+}
+
+func (server *Server) renderPriceRuleForm(w http.ResponseWriter, r *http.Request, rule *models.PriceRule, formErr error) {
+	render := render.New(render.Options{
+		Layout:     "layout",
+		Extensions: []string{".html", ".tmpl"},
+	})
+
+	status := http.StatusOK
+	data := server.priceRuleFormData(w, r)
+	data["rule"] = rule
+	data["types"] = models.PriceRuleTypes
+	if formErr != nil {
+		status = http.StatusUnprocessableEntity
+		data["error"] = []string{formErr.Error()}
+	}
+
+	_ = render.HTML(w, status, "admin_price_rule_form", data)
+}
+
+// priceRuleFormData berisi data pilihan kategori dan kelompok pelanggan untuk halaman aturan harga
+func (server *Server) priceRuleFormData(w http.ResponseWriter, r *http.Request) map[string]interface{} {
+	var categoryModel models.Category
+	categories, _ := categoryModel.GetCategories(server.DB)
+
+	var groupModel models.CustomerGroup
+	groups, _ := groupModel.GetCustomerGroups(server.DB)
+
+	categoryNames := map[string]string{}
+	for _, category := range categories {
+		categoryNames[category.ID] = category.Name
+	}
+	groupNames := map[string]string{}
+	for _, group := range groups {
+		groupNames[group.ID] = group.Name
+	}
+
+	return map[string]interface{}{
+		"categories":    categories,
+		"categoryNames": categoryNames,
+		"groups":        groups,
+		"groupNames":    groupNames,
+		"user":          auth.CurrentUser(server.DB, w, r),
+	}
+}
+
+// fillPriceRule mengisi aturan harga dari input form lalu memvalidasinya
+func (server *Server) fillPriceRule(rule *models.PriceRule, r *http.Request) error {
+	rule.Name = strings.TrimSpace(r.FormValue("name"))
+	rule.ProductID = strings.TrimSpace(r.FormValue("product_id"))
+	rule.UnitName = strings.TrimSpace(r.FormValue("unit_name"))
+	rule.CategoryID = r.FormValue("category_id")
+	rule.CustomerGroupID = r.FormValue("customer_group_id")
+	rule.Type = r.FormValue("type")
+
+	rule.Status = 0
+	if r.FormValue("status") == "1" {
+		rule.Status = 1
+	}
+
+	minQty, err := strconv.Atoi(r.FormValue("min_qty"))
+	if err != nil {
+		return errors.New("Qty minimum harus berupa angka")
+	}
+	rule.MinQty = minQty
+
+	rule.Value = decimal.Zero
+	if value := strings.TrimSpace(r.FormValue("value")); value != "" {
+		rule.Value, err = decimal.NewFromString(value)
+		if err != nil {
+			return errors.New("Nilai harus berupa angka")
+		}
+	}
+
+	rule.StartsAt, err = parsePriceRuleTime(r.FormValue("starts_at"))
+	if err != nil {
+		return errors.New("Format waktu mulai tidak valid")
+	}
+	rule.EndsAt, err = parsePriceRuleTime(r.FormValue("ends_at"))
+	if err != nil {
+		return errors.New("Format waktu selesai tidak valid")
+	}
+
+	if rule.ProductID != "" {
+		productModel := models.Product{}
+		product, err := productModel.FindByID(server.DB, rule.ProductID)
+		if err != nil {
+			return errors.New("Produk " + rule.ProductID + " tidak ditemukan")
+		}
+		if _, ok := product.FindUnit(rule.UnitName); rule.UnitName != "" && !ok {
+			return errors.New("Satuan " + rule.UnitName + " tidak ada pada produk " + product.Name)
+		}
+	}
+
+	return rule.Validate()
+}
+
+func parsePriceRuleTime(value string) (sql.NullTime, error) {
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+
+	t, err := time.ParseInLocation(priceRuleTimeLayout, value, time.Local)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+
+	return sql.NullTime{Time: t, Valid: true}, nil
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/unrolled/render"
+
+	"github.com/gieart87/gotoko/app/core/report"
+	"github.com/gieart87/gotoko/app/core/session/auth"
+)
+
+// AdminMarginReport menampilkan laporan pendapatan, HPP dan margin kotor
+func (server *Server) AdminMarginReport(w http.ResponseWriter, r *http.Request) {
+	render := render.New(render.Options{
+		Layout:     "layout",
+		Extensions: []string{".html", ".tmpl"},
+	})
+
+	status := http.StatusOK
+	data := map[string]interface{}{
+		"groups": report.MarginGroups,
+		"user":   auth.CurrentUser(server.DB, w, r),
+	}
+
+	filter, err := marginFilter(r)
+	if err == nil {
+		data["report"], err = report.Margin(server.DB, filter)
+	}
+	if err != nil {
+		status = http.StatusUnprocessableEntity
+		data["error"] = []string{err.Error()}
+	}
+	data["filter"] = filter
+
+	_ = render.HTML(w, status, "admin_margin_report", data)
+}
+
+// AdminExportMarginReport mengunduh laporan margin sebagai CSV dengan filter yang sama
+func (server *Server) AdminExportMarginReport(w http.ResponseWriter, r *http.Request) {
+	filter, err := marginFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	marginReport, err := report.Margin(server.DB, filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filename := marginReportFilename(filter)
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	_ = marginReport.WriteCSV(w)
+}
+
+// marginFilter membaca filter laporan dari query string
+func marginFilter(r *http.Request) (report.MarginFilter, error) {
+	q := r.URL.Query()
+	return parseMarginFilter(q.Get("from"), q.Get("to"), q.Get("group"))
+}
+
+// parseMarginFilter membuat filter laporan margin. Nilai kosong berarti bulan berjalan sampai hari ini, per produk.
+func parseMarginFilter(from string, to string, group string) (report.MarginFilter, error) {
+	now := time.Now()
+	filter := report.MarginFilter{
+		From:    time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local),
+		To:      time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local),
+		GroupBy: report.GroupProduct,
+	}
+
+	if group != "" {
+		filter.GroupBy = group
+	}
+
+	var err error
+	if from != "" {
+		filter.From, err = time.ParseInLocation(report.DateLayout, from, time.Local)
+		if err != nil {
+			return filter, errors.New("Format tanggal awal tidak valid")
+		}
+	}
+	if to != "" {
+		filter.To, err = time.ParseInLocation(report.DateLayout, to, time.Local)
+		if err != nil {
+			return filter, errors.New("Format tanggal akhir tidak valid")
+		}
+	}
+
+	return filter, nil
+}
+
+// exportMarginReport menyimpan laporan margin ke file CSV lalu menampilkan totalnya
+func (server *Server) exportMarginReport(filter report.MarginFilter, outputPath string) error {
+	marginReport, err := report.Margin(server.DB, filter)
+	if err != nil {
+		return err
+	}
+
+	if outputPath == "" {
+		outputPath = marginReportFilename(filter)
+	}
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	err = marginReport.WriteCSV(file)
+	if err != nil {
+		return fmt.Errorf("gagal menyimpan laporan margin: %w", err)
+	}
+
+	total := marginReport.Total
+	fmt.Printf("Pendapatan %s, HPP %s, margin %s (%s%%) dari %d kelompok\n",
+		total.Revenue.StringFixed(2), total.Cost.StringFixed(2), total.Margin().StringFixed(2), total.MarginPercent(), len(marginReport.Rows))
+	if marginReport.MissingCost > 0 {
+		fmt.Printf("Peringatan: %d item order belum memiliki harga pokok\n", marginReport.MissingCost)
+	}
+	fmt.Println("Report saved to", outputPath)
+
+	return nil
+}
+
+// marginReportFilename adalah nama file CSV laporan margin, misalnya margin-product-2026-10-01-2026-10-31.csv
+func marginReportFilename(filter report.MarginFilter) string {
+	return fmt.Sprintf("margin-%s-%s-%s.csv", filter.GroupBy, filter.From.Format(report.DateLayout), filter.To.Format(report.DateLayout))
+}
@@ -0,0 +1,247 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+	"github.com/unrolled/render"
+
+	"github.com/gieart87/gotoko/app/core/money"
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/core/session/flash"
+	"github.com/gieart87/gotoko/app/models"
+)
+
+// AdminVouchers menampilkan daftar voucher
+func (server *Server) AdminVouchers(w http.ResponseWriter, r *http.Request) {
+	render := render.New(render.Options{
+		Layout:     "layout",
+		Extensions: []string{".html", ".tmpl"},
+	})
+
+	var voucherModel models.Voucher
+	vouchers, err := voucherModel.GetVouchers(server.DB)
+	if err != nil {
+		http.Error(w, "Gagal mengambil voucher", http.StatusInternalServerError)
+		return
+	}
+
+	// Jumlah pemakaian per ID voucher, termasuk pada order yang dibatalkan
+	type voucherUsageCount struct {
+		VoucherID string
+		Total     int
+	}
+	var counts []voucherUsageCount
+	server.DB.Model(&models.VoucherUsage{}).Select("voucher_id, count(*) as total").Group("voucher_id").Scan(&counts)
+
+	usages := map[string]int{}
+	for _, count := range counts {
+		usages[count.VoucherID] = count.Total
+	}
+
+	data := server.voucherFormData(w, r)
+	data["vouchers"] = vouchers
+	data["usages"] = usages
+	data["success"] = flash.GetFlash(w, r, "success")
+	data["error"] = flash.GetFlash(w, r, "error")
+
+	_ = render.HTML(w, http.StatusOK, "admin_vouchers", data)
+}
+
+// AdminNewVoucher menampilkan form voucher baru
+func (server *Server) AdminNewVoucher(w http.ResponseWriter, r *http.Request) {
+	server.renderVoucherForm(w, r, &models.Voucher{Type: models.VoucherTypePercent, UsageLimitPerUser: 1, Status: 1}, nil)
+}
+
+// AdminCreateVoucher menyimpan voucher baru
+func (server *Server) AdminCreateVoucher(w http.ResponseWriter, r *http.Request) {
+	voucher := &models.Voucher{}
+	err := server.fillVoucher(voucher, r)
+	if err != nil {
+		server.renderVoucherForm(w, r, voucher, err)
+		return
+	}
+
+	err = server.DB.Create(voucher).Error
+	if err != nil {
+		server.renderVoucherForm(w, r, voucher, errors.New("Gagal menyimpan voucher, pastikan kode belum dipakai voucher lain"))
+		return
+	}
+
+	flash.SetFlash(w, r, "success", "Voucher berhasil disimpan")
+	http.Redirect(w, r, "/admin/vouchers", http.StatusSeeOther)
+}
+
+// AdminEditVoucher menampilkan form ubah voucher
+func (server *Server) AdminEditVoucher(w http.ResponseWriter, r *http.Request) {
+	var voucherModel models.Voucher
+	voucher, err := voucherModel.FindByID(server.DB, mux.Vars(r)["id"])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	server.renderVoucherForm(w, r, voucher, nil)
+}
+
+// AdminUpdateVoucher menyimpan perubahan voucher
+func (server *Server) AdminUpdateVoucher(w http.ResponseWriter, r *http.Request) {
+	var voucherModel models.Voucher
+	voucher, err := voucherModel.FindByID(server.DB, mux.Vars(r)["id"])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	err = server.fillVoucher(voucher, r)
+	if err != nil {
+		server.renderVoucherForm(w, r, voucher, err)
+		return
+	}
+
+	err = server.DB.Save(voucher).Error
+	if err != nil {
+		server.renderVoucherForm(w, r, voucher, errors.New("Gagal menyimpan voucher, pastikan kode belum dipakai voucher lain"))
+		return
+	}
+
+	flash.SetFlash(w, r, "success", "Voucher berhasil diubah")
+	http.Redirect(w, r, "/admin/vouchers", http.StatusSeeOther)
+}
+
+// AdminDeleteVoucher menghapus voucher. Riwayat pemakaiannya pada order tetap disimpan.
+func (server *Server) AdminDeleteVoucher(w http.ResponseWriter, r *http.Request) {
+	var voucherModel models.Voucher
+	voucher, err := voucherModel.FindByID(server.DB, mux.Vars(r)["id"])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	err = server.DB.Delete(voucher).Error
+	if err != nil {
+		flash.SetFlash(w, r, "error", "Gagal menghapus voucher")
+	} else {
+		flash.SetFlash(w, r, "success", "Voucher berhasil dihapus")
+	}
+
+	http.Redirect(w, r, "/admin/vouchers", http.StatusSeeOther)
+}
+
+func (server *Server) renderVoucherForm(w http.ResponseWriter, r *http.Request, voucher *models.Voucher, formErr error) {
+	render := render.New(render.Options{
+		Layout:     "layout",
+		Extensions: []string{".html", ".tmpl"},
+	})
+
+	status := http.StatusOK
+	data := server.voucherFormData(w, r)
+	data["voucher"] = voucher
+	data["types"] = models.VoucherTypes
+	if formErr != nil {
+		status = http.StatusUnprocessableEntity
+		data["error"] = []string{formErr.Error()}
+	}
+
+	_ = render.HTML(w, status, "admin_voucher_form", data)
+}
+
+// voucherFormData berisi data pilihan kategori untuk halaman voucher
+func (server *Server) voucherFormData(w http.ResponseWriter, r *http.Request) map[string]interface{} {
+	var categoryModel models.Category
+	categories, _ := categoryModel.GetCategories(server.DB)
+
+	categoryNames := map[string]string{}
+	for _, category := range categories {
+		categoryNames[category.ID] = category.Name
+	}
+
+	return map[string]interface{}{
+		"categories":    categories,
+		"categoryNames": categoryNames,
+		"user":          auth.CurrentUser(server.DB, w, r),
+	}
+}
+
+// fillVoucher mengisi voucher dari input form lalu memvalidasinya
+func (server *Server) fillVoucher(voucher *models.Voucher, r *http.Request) error {
+	voucher.Code = models.NormalizeVoucherCode(r.FormValue("code"))
+	voucher.Name = strings.TrimSpace(r.FormValue("name"))
+	voucher.Type = r.FormValue("type")
+	voucher.ProductID = strings.TrimSpace(r.FormValue("product_id"))
+	voucher.CategoryID = r.FormValue("category_id")
+
+	voucher.Status = 0
+	if r.FormValue("status") == "1" {
+		voucher.Status = 1
+	}
+
+	var err error
+	voucher.Value = decimal.Zero
+	if value := strings.TrimSpace(r.FormValue("value")); value != "" {
+		voucher.Value, err = decimal.NewFromString(value)
+		if err != nil {
+			return errors.New("Nilai potongan harus berupa angka")
+		}
+	}
+
+	voucher.MinSpend, err = parseMoneyField(r.FormValue("min_spend"))
+	if err != nil {
+		return errors.New("Minimal belanja harus berupa angka")
+	}
+	voucher.MaxDiscount, err = parseMoneyField(r.FormValue("max_discount"))
+	if err != nil {
+		return errors.New("Potongan maksimum harus berupa angka")
+	}
+
+	voucher.UsageLimit, err = parseLimitField(r.FormValue("usage_limit"))
+	if err != nil {
+		return errors.New("Kuota voucher harus berupa angka")
+	}
+	voucher.UsageLimitPerUser, err = parseLimitField(r.FormValue("usage_limit_per_user"))
+	if err != nil {
+		return errors.New("Batas per pelanggan harus berupa angka")
+	}
+
+	voucher.StartsAt, err = parsePriceRuleTime(r.FormValue("starts_at"))
+	if err != nil {
+		return errors.New("Format waktu mulai tidak valid")
+	}
+	voucher.EndsAt, err = parsePriceRuleTime(r.FormValue("ends_at"))
+	if err != nil {
+		return errors.New("Format waktu selesai tidak valid")
+	}
+
+	if voucher.ProductID != "" {
+		productModel := models.Product{}
+		if _, err := productModel.FindByID(server.DB, voucher.ProductID); err != nil {
+			return errors.New("Produk " + voucher.ProductID + " tidak ditemukan")
+		}
+	}
+
+	return voucher.Validate()
+}
+
+// parseMoneyField membaca input rupiah, kosong berarti nol
+func parseMoneyField(value string) (money.Money, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return money.Zero, nil
+	}
+
+	return money.Parse(value)
+}
+
+// parseLimitField membaca input batas pemakaian, kosong berarti tanpa batas
+func parseLimitField(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(value)
+}
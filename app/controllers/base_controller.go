@@ -1,39 +1,44 @@
 package controllers
 
 import (
-	"bytes"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"math"
 	"net/http"
 	"os"
-	"strconv"
+	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/360EntSecGroup-Skylar/excelize"
-	"github.com/google/uuid"
 	"github.com/gorilla/sessions"
-	"github.com/gosimple/slug"
-	"github.com/shopspring/decimal"
 
+	"github.com/gieart87/gotoko/app/core/importer"
+	"github.com/gieart87/gotoko/app/core/payment"
+	"github.com/gieart87/gotoko/app/core/pricing"
+	"github.com/gieart87/gotoko/app/core/shipping"
+	"github.com/gieart87/gotoko/app/core/stock"
 	"github.com/gieart87/gotoko/app/models"
+	"github.com/gieart87/gotoko/database/migrations"
 	"github.com/gieart87/gotoko/database/seeders"
+	_ "github.com/glebarez/go-sqlite"
 	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
 	"github.com/urfave/cli"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 // Server struct digunakan untuk menyimpan konfigurasi utama aplikasi
 type Server struct {
-	DB        *gorm.DB    // Koneksi ke database menggunakan GORM
-	Router    *mux.Router // Router untuk mengatur rute aplikasi
-	AppConfig *AppConfig  // Konfigurasi aplikasi seperti nama, lingkungan, dan URL
+	DB        *gorm.DB                  // Koneksi ke database menggunakan GORM
+	Router    *mux.Router               // Router untuk mengatur rute aplikasi
+	AppConfig *AppConfig                // Konfigurasi aplikasi seperti nama, lingkungan, dan URL
+	Shipping  shipping.ShippingProvider // Layanan pengiriman yang digunakan (Biteship atau fake)
+	Payment   payment.PaymentGateway    // Payment gateway yang digunakan (Midtrans atau simulator)
+	Pricing   *pricing.Engine           // Mesin aturan harga untuk menghitung harga item keranjang
+	StockHold time.Duration             // Lama stok ditahan untuk order yang belum dibayar
 }
 
 // AppConfig struct digunakan untuk menyimpan konfigurasi aplikasi
@@ -42,6 +47,15 @@ type AppConfig struct {
 	AppEnv  string // Lingkungan aplikasi (misalnya: development, production)
 	AppPort string // Port yang digunakan aplikasi
 	AppURL  string // URL dasar aplikasi
+
+	ShippingProvider string // Provider pengiriman yang digunakan (biteship, fake)
+	PaymentGateway   string // Payment gateway yang digunakan (midtrans, simulator)
+
+	TaxPPNRate   string // Tarif PPN dalam persen, 0 jika toko belum PKP
+	TaxPriceMode string // Harga jual belum (exclusive) atau sudah (inclusive) termasuk PPN
+
+	StockHoldDuration  string // Lama stok ditahan untuk order yang belum dibayar, misalnya 24h
+	StockSweepInterval string // Jeda pemeriksaan reservasi stok yang kedaluwarsa, misalnya 1m
 }
 
 // DBConfig struct digunakan untuk menyimpan konfigurasi database
@@ -51,7 +65,7 @@ type DBConfig struct {
 	DBPassword string // Password untuk mengakses database
 	DBName     string // Nama database yang akan digunakan
 	DBPort     string // Port koneksi database
-	DBDriver   string // Driver database (misalnya: postgres, mysql)
+	DBDriver   string // Driver database (misalnya: postgres, mysql, sqlite)
 }
 
 // PageLink struct digunakan untuk membuat tautan halaman dalam pagination
@@ -113,6 +127,10 @@ func (server *Server) Run(addr string) {
 	// Menampilkan pesan bahwa server mendengarkan pada port tertentu
 	fmt.Printf("Listening to port %s", addr)
 
+	// Melepas stok yang ditahan order yang batal atau tidak dibayar secara berkala
+	interval := parseDuration("STOCK_SWEEP_INTERVAL", server.AppConfig.StockSweepInterval, stock.DefaultSweepInterval)
+	stock.NewSweeper(server.DB, interval).Start(nil)
+
 	// Menjalankan server HTTP dan mencatat error jika terjadi
 	log.Fatal(http.ListenAndServe(addr, server.Router))
 }
@@ -121,15 +139,23 @@ func (server *Server) Run(addr string) {
 func (server *Server) initializeDB(dbConfig DBConfig) {
 	var err error
 
-	// Membuat DSN untuk PostgreSQL jika driver adalah postgres
-	if dbConfig.DBDriver == "postgres" {
+	switch dbConfig.DBDriver {
+	case "postgres":
+		// Membuat DSN untuk PostgreSQL jika driver adalah postgres
 		dsn := fmt.Sprintf(
 			"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Jakarta",
 			dbConfig.DBHost, dbConfig.DBUser, dbConfig.DBPassword, dbConfig.DBName, dbConfig.DBPort,
 		)
 		server.DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{}) // Membuka koneksi ke database PostgreSQL
-	} else {
-		// Membuat DSN untuk MySQL jika driver bukan postgres
+	case "sqlite":
+		// DB_NAME berisi path file database, atau ":memory:" untuk database di memori.
+		// Driver "sqlite" berasal dari glebarez/go-sqlite (pure Go), jadi tidak butuh cgo.
+		server.DB, err = gorm.Open(sqlite.Dialector{DriverName: "sqlite", DSN: dbConfig.DBName}, &gorm.Config{})
+		if err == nil && isSQLiteMemory(dbConfig.DBName) {
+			err = server.initializeMemoryDB()
+		}
+	default:
+		// Membuat DSN untuk MySQL jika driver bukan postgres atau sqlite
 		dsn := fmt.Sprintf(
 			"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 			dbConfig.DBUser, dbConfig.DBPassword, dbConfig.DBHost, dbConfig.DBPort, dbConfig.DBName,
@@ -142,30 +168,112 @@ func (server *Server) initializeDB(dbConfig DBConfig) {
 	}
 }
 
+// isSQLiteMemory mengecek apakah DSN SQLite menunjuk ke database di memori
+func isSQLiteMemory(dsn string) bool {
+	return dsn == "" || strings.Contains(dsn, ":memory:") || strings.Contains(dsn, "mode=memory")
+}
+
+// initializeMemoryDB menyiapkan database SQLite di memori. Setiap koneksi baru akan mendapat
+// database kosong, sehingga koneksi dibatasi satu lalu skema langsung dimigrasi.
+func (server *Server) initializeMemoryDB() error {
+	sqlDB, err := server.DB.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetConnMaxLifetime(0)
+
+	return migrations.Migrate(server.DB)
+}
+
 // initializeAppConfig menyimpan konfigurasi aplikasi ke dalam server
 func (server *Server) initializeAppConfig(appConfig AppConfig) {
 	server.AppConfig = &appConfig
+	server.Shipping = shipping.NewProvider(appConfig.ShippingProvider)
+	server.Payment = payment.NewGateway(appConfig.PaymentGateway, appConfig.AppPort)
+	server.Pricing = pricing.NewEngine(server.DB)
+	server.StockHold = parseDuration("STOCK_HOLD_DURATION", appConfig.StockHoldDuration, stock.DefaultHoldDuration)
+
+	taxSetting, err := newTaxSetting(appConfig.TaxPPNRate, appConfig.TaxPriceMode)
+	if err != nil {
+		log.Fatalf("Konfigurasi pajak tidak valid: %v", err)
+	}
+	models.SetTaxSetting(taxSetting)
 }
 
-// dbMigrate melakukan migrasi database dengan semua model yang terdaftar
-func (server *Server) dbMigrate() {
-	// Iterasi setiap model yang terdaftar untuk migrasi
-	for _, model := range models.RegisterModels() {
-		// Melakukan migrasi model dengan mode debug untuk log detail
-		err := server.DB.Debug().AutoMigrate(model.Model)
-		// Jika terjadi error saat migrasi, catat dan hentikan program
-		if err != nil {
-			log.Fatal(err)
+// parseDuration membaca durasi konfigurasi seperti 30m atau 24h. Nilai kosong berarti fallback.
+func parseDuration(name string, value string, fallback time.Duration) time.Duration {
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Fatalf("%s %q harus berupa durasi positif, misalnya 30m atau 24h", name, value)
+	}
+
+	return duration
+}
+
+// newTaxSetting membaca konfigurasi pajak dari TAX_PPN_RATE dan TAX_PRICE_MODE
+func newTaxSetting(rate string, mode string) (models.TaxSetting, error) {
+	setting := models.TaxSetting{PPNRate: decimal.Zero, Mode: models.TaxModeExclusive}
+
+	if rate != "" {
+		ppnRate, err := decimal.NewFromString(rate)
+		if err != nil || ppnRate.IsNegative() || ppnRate.GreaterThan(decimal.NewFromInt(100)) {
+			return setting, fmt.Errorf("TAX_PPN_RATE %q harus berupa persen antara 0 dan 100", rate)
 		}
+		setting.PPNRate = ppnRate
+	}
+
+	switch mode {
+	case "", models.TaxModeExclusive:
+	case models.TaxModeInclusive:
+		setting.Mode = models.TaxModeInclusive
+	default:
+		return setting, fmt.Errorf("TAX_PRICE_MODE %q harus %s atau %s", mode, models.TaxModeExclusive, models.TaxModeInclusive)
 	}
-	// Menampilkan pesan sukses setelah migrasi selesai
-	fmt.Println("Database migrated successfully.")
+
+	return setting, nil
+}
+
+// dbMigrate menjalankan semua migrasi yang belum diterapkan
+func (server *Server) dbMigrate() error {
+	return migrations.Migrate(server.DB)
+}
+
+// dbRollback membatalkan batch migrasi terakhir, atau sejumlah step migrasi terakhir
+func (server *Server) dbRollback(steps int) error {
+	return migrations.Rollback(server.DB, steps)
+}
+
+// dbMigrateStatus menampilkan daftar migrasi beserta statusnya
+func (server *Server) dbMigrateStatus() error {
+	statuses, err := migrations.Status(server.DB)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-8s %-6s %-20s %s\n", "Status", "Batch", "Applied At", "Migration")
+	for _, status := range statuses {
+		if !status.Applied {
+			fmt.Printf("%-8s %-6s %-20s %s\n", "Pending", "-", "-", status.Version)
+			continue
+		}
+		fmt.Printf("%-8s %-6d %-20s %s\n", "Ran", status.Batch, status.AppliedAt.Format("2006-01-02 15:04:05"), status.Version)
+	}
+
+	return nil
 }
 
 func (server *Server) InitCommands(config AppConfig, dbConfig DBConfig) {
 	// Inisialisasi koneksi database dengan memanggil fungsi initializeDB
 	server.initializeDB(dbConfig)
 
+	// Menyimpan konfigurasi aplikasi beserta provider pengiriman dan payment gateway
+	server.initializeAppConfig(config)
+
 	// Membuat aplikasi CLI baru menggunakan paket urfave/cli
 	cmdApp := cli.NewApp()
 
@@ -173,10 +281,52 @@ func (server *Server) InitCommands(config AppConfig, dbConfig DBConfig) {
 	cmdApp.Commands = []cli.Command{
 		{
 			// Nama perintah pertama adalah "db:migrate"
-			Name: "db:migrate",
+			Name:  "db:migrate",
+			Usage: "Jalankan migrasi yang belum diterapkan",
 			Action: func(c *cli.Context) error {
 				// Menjalankan migrasi database
-				server.dbMigrate()
+				err := server.dbMigrate()
+				if err != nil {
+					log.Fatal(err)
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "db:rollback",
+			Usage: "Batalkan batch migrasi terakhir",
+			Flags: []cli.Flag{
+				cli.IntFlag{Name: "step", Usage: "jumlah migrasi terakhir yang dibatalkan (0 = satu batch)"},
+			},
+			Action: func(c *cli.Context) error {
+				err := server.dbRollback(c.Int("step"))
+				if err != nil {
+					log.Fatal(err)
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "db:migrate:status",
+			Usage: "Tampilkan status setiap migrasi",
+			Action: func(c *cli.Context) error {
+				err := server.dbMigrateStatus()
+				if err != nil {
+					log.Fatal(err)
+				}
+				return nil
+			},
+		},
+		{
+			Name:      "db:make-migration",
+			Usage:     "Buat file migrasi baru di database/migrations",
+			ArgsUsage: "<nama_migrasi>",
+			Action: func(c *cli.Context) error {
+				path, err := migrations.MakeMigration("database/migrations", c.Args().First())
+				if err != nil {
+					log.Fatal(err)
+				}
+				fmt.Println("Created migration:", path)
 				return nil
 			},
 		},
@@ -194,20 +344,78 @@ func (server *Server) InitCommands(config AppConfig, dbConfig DBConfig) {
 			},
 		},
 		{
-			// Nama perintah kedua adalah "db:seed"
-			Name: "db:excel2",
+			Name:  "payments:reconcile",
+			Usage: "Cocokkan order UNPAID dengan status transaksi di payment gateway",
+			Action: func(c *cli.Context) error {
+				err := server.reconcilePayments()
+				if err != nil {
+					log.Fatal(err)
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "stock:sweep",
+			Usage: "Lepas stok yang ditahan order yang batal atau tidak dibayar sampai kedaluwarsa",
 			Action: func(c *cli.Context) error {
-				err := server.importProductsFromExcel2("PRODUK.xlsx")
+				released, err := stock.NewSweeper(server.DB, stock.DefaultSweepInterval).Sweep(time.Now())
 				if err != nil {
 					log.Fatal(err)
 				}
+				fmt.Printf("Released stock reservations of %d orders\n", released)
 				return nil
 			},
 		},
 		{
-			Name: "db:excel",
+			Name:      "db:images",
+			Usage:     "Import gambar produk dari direktori, nama file berupa ID atau SKU produk",
+			ArgsUsage: "<direktori>",
+			Flags: []cli.Flag{
+				cli.BoolFlag{Name: "replace", Usage: "hapus gambar lama produk sebelum menyimpan gambar baru"},
+				cli.BoolFlag{Name: "dry-run", Usage: "tampilkan hasil tanpa menyimpan file maupun data"},
+			},
 			Action: func(c *cli.Context) error {
-				err := server.importProductsFromExcel("PRODUK.xlsx")
+				if c.NArg() == 0 {
+					log.Fatal("direktori gambar wajib diisi")
+				}
+				err := server.importProductImages(c.Args().First(), c.Bool("replace"), c.Bool("dry-run"))
+				if err != nil {
+					log.Fatal(err)
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "report:margin",
+			Usage: "Ekspor laporan pendapatan, HPP dan margin kotor ke CSV",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "from", Usage: "tanggal awal order, format 2006-01-02 (default awal bulan ini)"},
+				cli.StringFlag{Name: "to", Usage: "tanggal akhir order, format 2006-01-02 (default hari ini)"},
+				cli.StringFlag{Name: "group", Value: "product", Usage: "pengelompokan: product, category, supplier, day atau month"},
+				cli.StringFlag{Name: "output", Usage: "path file CSV (default margin-<group>-<from>-<to>.csv)"},
+			},
+			Action: func(c *cli.Context) error {
+				filter, err := parseMarginFilter(c.String("from"), c.String("to"), c.String("group"))
+				if err == nil {
+					err = server.exportMarginReport(filter, c.String("output"))
+				}
+				if err != nil {
+					log.Fatal(err)
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "db:excel",
+			Usage: "Import atau perbarui produk dari spreadsheet POS",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "file", Value: "PRODUK.xlsx", Usage: "path file Excel"},
+				cli.StringFlag{Name: "mapping", Value: "database/mappings/products.json", Usage: "file mapping header kolom ke field produk"},
+				cli.BoolFlag{Name: "dry-run", Usage: "tampilkan perubahan tanpa menyimpan ke database"},
+				cli.StringFlag{Name: "report", Usage: "path file laporan (default import-report-<waktu>.txt)"},
+			},
+			Action: func(c *cli.Context) error {
+				err := server.importProductsFromExcel(c.String("file"), c.String("mapping"), c.Bool("dry-run"), c.String("report"))
 				if err != nil {
 					log.Fatal(err)
 				}
@@ -222,133 +430,57 @@ func (server *Server) InitCommands(config AppConfig, dbConfig DBConfig) {
 	}
 }
 
-func (server *Server) importProductsFromExcel(filePath string) error {
-	// Buka file Excel menggunakan excelize
-	f, err := excelize.OpenFile(filePath)
+// importProductsFromExcel meng-import produk dari spreadsheet POS. Kolom dibaca berdasarkan header
+// sesuai file mapping, produk yang sudah ada diperbarui, produk baru dibuat, dan ringkasannya
+// disimpan ke reportPath. Kesalahan per baris disimpan ke file CSV terpisah.
+func (server *Server) importProductsFromExcel(filePath string, mappingPath string, dryRun bool, reportPath string) error {
+	mapping, err := importer.LoadMapping(mappingPath)
 	if err != nil {
-		return fmt.Errorf("gagal membuka file Excel: %w", err)
+		return err
 	}
 
-	// Ambil nama sheet pertama
-	sheetName := f.GetSheetName(1)
-	if sheetName == "" {
-		return fmt.Errorf("sheet tidak ditemukan dalam file Excel")
+	report, err := importer.NewProductImporter(server.DB, mapping, dryRun).Import(filePath)
+	if err != nil {
+		return err
 	}
 
-	// Ambil semua baris dari sheet
-	rows := f.GetRows(sheetName)
-
-	// Iterasi data dan masukkan ke database
-	for i, row := range rows {
-		if i == 0 {
-			continue // Lewati header
-		}
-
-		// Pastikan jumlah kolom mencukupi
-		if len(row) < 20 {
-			log.Printf("Baris %d dilewati karena kolom tidak mencukupi", i+1)
-			continue
-		}
-
-		// Parsing data dari Excel ke Product
-		product := models.Product{
-			ID:          row[0],
-			Name:        row[1],
-			SATUAN1:     row[3],
-			SATUAN2:     row[4],
-			SATUAN3:     row[5],
-			KONVERSI1:   toInt(row[6]),
-			KONVERSI2:   toInt(row[7]),
-			KONVERSI3:   toInt(row[8]),
-			HARGAPOKOK1: toDecimal(row[9]),
-			HARGAPOKOK2: toDecimal(row[10]),
-			HARGAPOKOK3: toDecimal(row[11]),
-			HJ1:         toDecimal(row[12]),
-			HJ2:         toDecimal(row[13]),
-			HJ3:         toDecimal(row[14]),
-			HJ2_1:       toDecimal(row[15]),
-			HJ2_2:       toDecimal(row[16]),
-			HJ2_3:       toDecimal(row[17]),
-			Stock:       toInt(row[18]),
-			Supplier:    row[19],
-			Categories:  row[2],
-			Sku:         slug.Make(fmt.Sprintf("%s-%s", row[2], row[1])),
-			Slug:        slug.Make(fmt.Sprintf("%s-%s", row[2], row[1])),
-			Status:      1,
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-		}
-
-		// Simpan ke database
-		if err := server.DB.Create(&product).Error; err != nil {
-			log.Printf("Gagal menyimpan produk ID %s: %v", product.ID, err)
-		}
+	if dryRun {
+		report.WriteDetails(os.Stdout)
+		fmt.Println()
 	}
+	report.WriteSummary(os.Stdout)
 
-	log.Println("Import data dari Excel berhasil")
-	return nil
-}
-
-// Fungsi bantu untuk konversi string ke integer
-func toInt(s string) int {
-	i, err := strconv.Atoi(s)
-	if err != nil {
-		return 0
+	if reportPath == "" {
+		reportPath = fmt.Sprintf("import-report-%s.txt", time.Now().Format("20060102-150405"))
 	}
-	return i
-}
-
-func (server *Server) importProductsFromExcel2(filePath string) error {
-	// Buka file Excel menggunakan excelize
-	f, err := excelize.OpenFile(filePath)
+	err = report.Save(reportPath)
 	if err != nil {
-		return fmt.Errorf("gagal membuka file Excel: %w", err)
+		return fmt.Errorf("gagal menyimpan laporan import: %w", err)
 	}
+	fmt.Println("Report saved to", reportPath)
 
-	// Ambil nama sheet pertama
-	sheetName := f.GetSheetName(1)
-	if sheetName == "" {
-		return fmt.Errorf("sheet tidak ditemukan dalam file Excel")
-	}
-
-	// Ambil semua baris dari sheet
-	rows := f.GetRows(sheetName)
-
-	// Iterasi data dan masukkan ke database
-	for i, row := range rows {
-		if i == 0 {
-			continue // Lewati header
-		}
-
-		// Pastikan jumlah kolom mencukupi
-		if len(row) < 20 {
-			log.Printf("Baris %d dilewati karena kolom tidak mencukupi", i+1)
-			continue
-		}
-
-		// Parsing data dari Excel ke Product
-		productimage := models.ProductImage{
-			ID:        uuid.New().String(),
-			ProductID: row[0],
-		}
-
-		// Simpan ke database
-		if err := server.DB.Create(&productimage).Error; err != nil {
-			log.Printf("Gagal menyimpan produk ID %s: %v", productimage.ProductID, err)
+	if report.Failed > 0 {
+		errorPath := strings.TrimSuffix(reportPath, filepath.Ext(reportPath)) + "-errors.csv"
+		err = report.SaveErrors(errorPath)
+		if err != nil {
+			return fmt.Errorf("gagal menyimpan laporan error import: %w", err)
 		}
+		fmt.Println("Row errors saved to", errorPath)
 	}
 
-	log.Println("Import data dari Excel berhasil")
 	return nil
 }
 
-// Fungsi bantu untuk konversi string ke decimal.Decimal
-func toDecimal(s string) decimal.Decimal {
-	d, err := decimal.NewFromString(s)
+// importProductImages mengimpor gambar produk dari sourceDir dan membuat varian ukurannya di direktori assets
+func (server *Server) importProductImages(sourceDir string, replace bool, dryRun bool) error {
+	report, err := importer.NewImageImporter(server.DB, "assets", replace, dryRun).Import(sourceDir)
 	if err != nil {
-		return decimal.Zero
+		return err
 	}
-	return d
+
+	report.WriteSummary(os.Stdout)
+
+	return nil
 }
 
 // convertCityIDToBiteshipAreaID mengkonversi City ID Indonesia ke Biteship Area ID
@@ -781,297 +913,3 @@ func GetPaginationLinks(config *AppConfig, params PaginationParams) (PaginationL
 		Links: links,
 	}, nil
 }
-
-type ShippingFeeParams struct {
-	Origin      string
-	Destination string
-	Weight      int
-	Couriers    string
-}
-
-// CalculateShippingFeeBiteship mengirim permintaan POST ke API Biteship untuk menghitung biaya pengiriman
-func (server *Server) CalculateShippingFeeBiteship(params ShippingFeeParams) ([]models.Pricing, error) {
-	// DEBUG: Log what we're sending to Biteship
-	apiKey := os.Getenv("API_BITESHIP")
-	isTestingMode := strings.Contains(apiKey, "biteship_test")
-	modeLabel := "🚀 PRODUCTION"
-	if isTestingMode {
-		modeLabel = "🧪 TESTING"
-	}
-
-	log.Printf("🚚 BITESHIP API REQUEST (%s MODE):", modeLabel)
-	log.Printf("   Origin Area ID: %s", params.Origin)
-	log.Printf("   Destination Area ID: %s", params.Destination)
-	log.Printf("   Couriers: %s", params.Couriers)
-	log.Printf("   Weight: %d grams", params.Weight)
-
-	// Validate input parameters
-	if params.Origin == "" {
-		log.Printf("❌ ERROR: Origin area ID is empty")
-		return nil, fmt.Errorf("origin area ID cannot be empty")
-	}
-	if params.Destination == "" {
-		log.Printf("❌ ERROR: Destination area ID is empty")
-		return nil, fmt.Errorf("destination area ID cannot be empty")
-	}
-	if params.Couriers == "" {
-		log.Printf("❌ ERROR: Couriers is empty")
-		return nil, fmt.Errorf("couriers cannot be empty")
-	}
-
-	// Membuat payload data permintaan sesuai format API Biteship
-	payload := models.CourierRequest{
-		OriginAreaID:      params.Origin,
-		DestinationAreaID: params.Destination,
-		Couriers:          params.Couriers,
-		Items: []models.Item{
-			{
-				Name:        "Cart Items",                        // Nama item default
-				Description: "Combined items from shopping cart", // Deskripsi item default
-				Value:       100000,                              // Nilai item contoh
-				Length:      10,                                  // Panjang item dalam cm
-				Width:       10,                                  // Lebar item dalam cm
-				Height:      10,                                  // Tinggi item dalam cm
-				Weight:      params.Weight,                       // Berat item dari parameter
-				Quantity:    1,                                   // Jumlah item default
-			},
-		},
-	}
-
-	// Mengonversi payload ke format JSON
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err // Mengembalikan kesalahan jika proses marshaling gagal
-	}
-
-	// Menentukan URL endpoint API Biteship
-	url := "https://api.biteship.com/v1/rates/couriers"
-
-	// Membuat permintaan HTTP POST
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err // Mengembalikan kesalahan jika pembuatan permintaan gagal
-	}
-
-	// Menetapkan header permintaan
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("API_BITESHIP"))
-	log.Printf("Authorization Header: %s", req.Header.Get("Authorization"))
-
-	// Mengirim permintaan HTTP ke server
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err // Mengembalikan kesalahan jika permintaan gagal
-	}
-	defer resp.Body.Close() // Menutup body respons setelah selesai
-
-	// Membaca isi body respons
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err // Mengembalikan kesalahan jika pembacaan body gagal
-	}
-
-	// Memeriksa status HTTP dari respons
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error: %s", string(body)) // Mengembalikan kesalahan jika status tidak OK
-	}
-
-	// DEBUG: Log response from Biteship
-	log.Printf("📦 BITESHIP API RESPONSE: %s", string(body))
-
-	// Mengurai data JSON dari respons
-	var response models.CourierResponse
-	err = json.Unmarshal(body, &response)
-	if err != nil {
-		return nil, err // Mengembalikan kesalahan jika penguraian JSON gagal
-	}
-
-	// DEBUG: Log parsed response
-	log.Printf("✅ BITESHIP PARSED DATA:")
-	log.Printf("   Success: %v", response.Success)
-	log.Printf("   Origin: %+v", response.Origin)
-	log.Printf("   Destination: %+v", response.Destination)
-	log.Printf("   Pricing options: %d", len(response.Pricing))
-
-	// SPECIFIC DEBUG: Check if destination matches what we expect
-	log.Printf("🔍 DESTINATION CHECK:")
-	log.Printf("   Expected Destination Area ID: %s", params.Destination)
-	log.Printf("   Actual Destination from Biteship: %+v", response.Destination)
-
-	// Alert if there's a mismatch (Palaran should NOT return Sanga-sanga)
-	if params.Destination == "IDNP15IDNC383IDND4548" {
-		log.Printf("🎯 PALARAN REQUEST - Checking response...")
-		log.Printf("   Destination City: %s", response.Destination.City)
-		if response.Destination.City == "Sanga-sanga" {
-			log.Printf("🚨 CRITICAL: Palaran request returned Sanga-sanga!")
-		}
-	}
-
-	// Memeriksa keberhasilan respons dari API
-	if !response.Success {
-		return nil, errors.New(response.Message) // Mengembalikan pesan kesalahan dari API jika gagal
-	}
-
-	return response.Pricing, nil // Mengembalikan data harga pengiriman jika berhasil
-}
-
-// parseLtlng mengonversi string menjadi float64
-func parseLtlng(latitudeStr string) (float64, error) {
-	// Konversi string ke float64
-	latitude, err := strconv.ParseFloat(latitudeStr, 64)
-	if err != nil {
-		return 0, fmt.Errorf("gagal mengonversi latitude: %v", err) // Mengembalikan kesalahan jika konversi gagal
-	}
-
-	return latitude, nil // Mengembalikan nilai latitude jika berhasil
-}
-
-// CalculateShippingFeeBiteshipInstant menghitung biaya pengiriman instan menggunakan API Biteship
-// dengan parameter lokasi asal dan tujuan yang diberikan.
-func (server *Server) CalculateShippingFeeBiteshipInstant(params ShippingFeeParams) ([]models.Pricing, error) {
-	// Mengonversi koordinat asal ke latitude, jika terjadi kesalahan set nilai default
-	latitudeDestination, err := parseLtlng(params.Origin)
-	if err != nil {
-		// Updated coordinates for Toko Shafirda - Jl. KH. Harun Nafsi No.106, Loa Janan Ilir
-		latitudeDestination = -0.5262810043373423
-	}
-
-	// Mengonversi koordinat tujuan ke longitude, jika terjadi kesalahan set nilai default
-	longitudeDestination, err := parseLtlng(params.Destination)
-	if err != nil {
-		// Updated coordinates for Toko Shafirda - Jl. KH. Harun Nafsi No.106, Loa Janan Ilir
-		longitudeDestination = 117.13669626404219
-	}
-
-	// Membuat payload untuk permintaan API yang berisi data pengiriman
-	payload := models.CourierInstantRequest{
-		OriginLatitude:       -0.526313085327813,   // Latitude lokasi asal
-		OriginLongitude:      117.13666900992393,   // Longitude lokasi asal
-		DestinationLatitude:  latitudeDestination,  // Latitude lokasi tujuan
-		DestinationLongitude: longitudeDestination, // Longitude lokasi tujuan
-		Couriers:             "grab,gojek",         // Kurir yang digunakan
-		Items: []models.Item{
-			{
-				Name:        "Shoes",                 // Nama item
-				Description: "Black colored size 45", // Deskripsi item
-				Value:       199000,                  // Nilai item dalam rupiah
-				Length:      30,                      // Panjang item dalam cm
-				Width:       15,                      // Lebar item dalam cm
-				Height:      20,                      // Tinggi item dalam cm
-				Weight:      200,                     // Berat item dalam gram
-				Quantity:    2,                       // Jumlah item
-			},
-		},
-	}
-
-	// Mengubah payload menjadi format JSON untuk dikirim melalui API
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err // Mengembalikan error jika gagal membuat JSON
-	}
-
-	// URL endpoint API Biteship untuk menghitung biaya pengiriman
-	url := "https://api.biteship.com/v1/rates/couriers"
-
-	// Membuat permintaan HTTP POST ke API
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err // Mengembalikan error jika gagal membuat permintaan
-	}
-
-	// Menambahkan header ke permintaan HTTP
-	req.Header.Set("Content-Type", "application/json") // Menentukan tipe konten sebagai JSON
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("API_BITESHIP"))
-
-	// Membuat klien HTTP dan mengirim permintaan
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err // Mengembalikan error jika permintaan gagal
-	}
-	defer resp.Body.Close() // Menutup body respons setelah selesai
-
-	// Membaca respons dari API
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err // Mengembalikan error jika gagal membaca respons
-	}
-
-	// Mengecek status kode HTTP untuk memastikan respons sukses
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error: %s", string(body)) // Mengembalikan error jika API mengembalikan status selain 200 OK
-	}
-
-	// Mengurai respons JSON ke dalam struktur data `CourierResponse`
-	var response models.CourierResponse
-	err = json.Unmarshal(body, &response)
-	if err != nil {
-		return nil, err // Mengembalikan error jika gagal mengurai JSON
-	}
-
-	// Mengecek apakah API mengembalikan status sukses
-	if !response.Success {
-		return nil, fmt.Errorf("API returned error: %s", response.Message) // Mengembalikan error jika API mengembalikan pesan error
-	}
-
-	// Mengembalikan data harga pengiriman yang berhasil dihitung
-	return response.Pricing, nil
-}
-
-// perubahan API create biteship
-// CreateBiteshipOrder membuat pesanan baru menggunakan API Biteship
-func (server *Server) CreateBiteshipOrder(params models.OrderParams) (*models.OrderResponse, error) {
-	// Mengubah parameter pesanan menjadi format JSON untuk dikirimkan ke API
-	jsonData, err := json.Marshal(params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal order params: %w", err) // Mengembalikan error jika gagal melakukan serialisasi JSON
-	}
-
-	// URL endpoint API untuk membuat pesanan
-	url := "https://api.biteship.com/v1/orders"
-
-	// Membuat permintaan HTTP POST dengan data JSON
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err) // Mengembalikan error jika gagal membuat permintaan
-	}
-
-	// Menambahkan header untuk tipe konten dan otorisasi
-	req.Header.Set("Content-Type", "application/json")                   // Menentukan bahwa data yang dikirim adalah JSON
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("API_BITESHIP")) // Token API untuk autentikasi
-
-	// Membuat klien HTTP dan mengirim permintaan
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err) // Mengembalikan error jika permintaan gagal
-	}
-	defer resp.Body.Close() // Menutup body respons setelah selesai untuk menghindari kebocoran sumber daya
-
-	// Membaca respons dari API
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err) // Mengembalikan error jika gagal membaca body respons
-	}
-
-	// Memeriksa kode status HTTP untuk memastikan bahwa permintaan berhasil
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error: %s", string(body)) // Mengembalikan error jika API mengembalikan kode status selain 200 OK
-	}
-
-	// Mengurai data JSON dari respons ke dalam struktur data `OrderResponse`
-	var response models.OrderResponse
-	err = json.Unmarshal(body, &response)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err) // Mengembalikan error jika gagal mengurai JSON
-	}
-
-	// Memeriksa apakah respons dari API menunjukkan kesuksesan
-	if !response.Success {
-		return nil, errors.New(response.Message) // Mengembalikan error jika API mengembalikan status gagal
-	}
-
-	// Mengembalikan respons yang berhasil berupa data `OrderResponse`
-	return &response, nil
-}
@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/gieart87/gotoko/app/core/money"
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/models"
+)
+
+// cartResponse adalah isi keranjang yang dikembalikan API keranjang, dihitung ulang setiap kali keranjang berubah
+type cartResponse struct {
+	ID               string             `json:"id"`
+	Items            []cartItemResponse `json:"items"`
+	ItemCount        int                `json:"item_count"`
+	BaseTotalPrice   money.Money        `json:"base_total_price"`
+	TaxAmount        money.Money        `json:"tax_amount"`
+	DiscountAmount   money.Money        `json:"discount_amount"`
+	GrandTotal       money.Money        `json:"grand_total"`
+	TotalWeight      int                `json:"total_weight"`
+	VoucherCode      string             `json:"voucher_code,omitempty"`
+	PriceIncludesTax bool               `json:"price_includes_tax"`
+}
+
+// cartItemResponse adalah satu item keranjang beserta tingkat harga yang dipakai
+type cartItemResponse struct {
+	ID             string      `json:"id"`
+	ProductID      string      `json:"product_id"`
+	Name           string      `json:"name"`
+	Slug           string      `json:"slug"`
+	Unit           string      `json:"unit"`
+	Qty            int         `json:"qty"`
+	Price          money.Money `json:"price"`
+	PriceTier      string      `json:"price_tier"`
+	FlashSaleID    string      `json:"flash_sale_id,omitempty"`
+	BaseTotal      money.Money `json:"base_total"`
+	TaxAmount      money.Money `json:"tax_amount"`
+	DiscountAmount money.Money `json:"discount_amount"`
+	SubTotal       money.Money `json:"sub_total"`
+	Weight         int         `json:"weight"`
+}
+
+// cartItemRequest adalah isi permintaan JSON untuk menambah item atau mengubah qty item
+type cartItemRequest struct {
+	ProductID string `json:"product_id"`
+	Unit      string `json:"unit"`
+	Qty       int    `json:"qty"`
+}
+
+// GetCartAPI mengembalikan isi keranjang pelanggan atau tamu
+func (server *Server) GetCartAPI(w http.ResponseWriter, r *http.Request) {
+	cart, _ := GetShoppingCart(server.DB, server.GetShoppingCartID(w, r))
+
+	writeCartResponse(w, http.StatusOK, cart, "Success")
+}
+
+// AddCartItemAPI menambahkan item ke keranjang dengan body {"product_id", "unit", "qty"}
+func (server *Server) AddCartItemAPI(w http.ResponseWriter, r *http.Request) {
+	var request cartItemRequest
+	if !decodeCartRequest(w, r, &request) {
+		return
+	}
+
+	cart, _ := GetShoppingCart(server.DB, server.GetShoppingCartID(w, r))
+	_, err := server.addCartItem(cart, auth.CurrentUser(server.DB, w, r), request.ProductID, request.Unit, request.Qty)
+	if err != nil {
+		writeCartError(w, err, "Gagal menambahkan item ke keranjang")
+		return
+	}
+
+	cart, _ = GetShoppingCart(server.DB, cart.ID)
+	writeCartResponse(w, http.StatusCreated, cart, "Item berhasil ditambahkan")
+}
+
+// UpdateCartItemAPI mengubah qty satu item keranjang dengan body {"qty"}
+func (server *Server) UpdateCartItemAPI(w http.ResponseWriter, r *http.Request) {
+	var request cartItemRequest
+	if !decodeCartRequest(w, r, &request) {
+		return
+	}
+
+	cart, _ := GetShoppingCart(server.DB, server.GetShoppingCartID(w, r))
+	err := server.setCartItemQty(cart, auth.CurrentUser(server.DB, w, r), mux.Vars(r)["id"], request.Qty)
+	if err != nil {
+		writeCartError(w, err, "Gagal mengubah jumlah item")
+		return
+	}
+
+	cart, _ = GetShoppingCart(server.DB, cart.ID)
+	writeCartResponse(w, http.StatusOK, cart, "Jumlah item berhasil diubah")
+}
+
+// RemoveCartItemAPI menghapus satu item keranjang
+func (server *Server) RemoveCartItemAPI(w http.ResponseWriter, r *http.Request) {
+	cart, _ := GetShoppingCart(server.DB, server.GetShoppingCartID(w, r))
+
+	item, err := server.findCartItem(cart, mux.Vars(r)["id"])
+	if err == nil {
+		err = cart.RemoveItemByID(server.DB, item.ID)
+	}
+	if err != nil {
+		writeCartError(w, err, "Gagal menghapus item")
+		return
+	}
+
+	cart, _ = GetShoppingCart(server.DB, cart.ID)
+	writeCartResponse(w, http.StatusOK, cart, "Item berhasil dihapus")
+}
+
+// ClearCartAPI menghapus semua item dan voucher keranjang
+func (server *Server) ClearCartAPI(w http.ResponseWriter, r *http.Request) {
+	cart, _ := GetShoppingCart(server.DB, server.GetShoppingCartID(w, r))
+
+	err := cart.EmptyCart(server.DB)
+	if err != nil {
+		writeCartError(w, err, "Gagal mengosongkan keranjang")
+		return
+	}
+
+	cart, _ = GetShoppingCart(server.DB, cart.ID)
+	writeCartResponse(w, http.StatusOK, cart, "Keranjang berhasil dikosongkan")
+}
+
+// decodeCartRequest membaca body JSON permintaan API keranjang, menulis respons 400 jika formatnya salah
+func decodeCartRequest(w http.ResponseWriter, r *http.Request, request *cartItemRequest) bool {
+	err := json.NewDecoder(r.Body).Decode(request)
+	if err != nil {
+		writeJSONResult(w, Result{Code: http.StatusBadRequest, Message: "Format permintaan tidak valid"})
+		return false
+	}
+
+	return true
+}
+
+// writeCartResponse menulis isi keranjang sebagai respons JSON
+func writeCartResponse(w http.ResponseWriter, status int, cart *models.Cart, message string) {
+	response := cartResponse{
+		ID:               cart.ID,
+		Items:            []cartItemResponse{},
+		BaseTotalPrice:   cart.BaseTotalPrice,
+		TaxAmount:        cart.TaxAmount,
+		DiscountAmount:   cart.DiscountAmount,
+		GrandTotal:       cart.GrandTotal,
+		TotalWeight:      cart.TotalWeight,
+		VoucherCode:      cart.VoucherCode,
+		PriceIncludesTax: models.GetTaxSetting().Inclusive(),
+	}
+	for i := range cart.CartItems {
+		item := &cart.CartItems[i]
+		response.ItemCount += item.Qty
+		response.Items = append(response.Items, cartItemResponse{
+			ID:             item.ID,
+			ProductID:      item.ProductID,
+			Name:           item.Product.Name,
+			Slug:           item.Product.Slug,
+			Unit:           item.Unit,
+			Qty:            item.Qty,
+			Price:          item.Pricenew,
+			PriceTier:      item.PriceTier,
+			FlashSaleID:    item.FlashSaleID,
+			BaseTotal:      item.BaseTotal,
+			TaxAmount:      item.TaxAmount,
+			DiscountAmount: item.DiscountAmount,
+			SubTotal:       item.SubTotal,
+			Weight:         cartItemWeight(item),
+		})
+	}
+
+	writeJSONResult(w, Result{Code: status, Data: response, Message: message})
+}
+
+// writeCartError menulis kesalahan API keranjang. Selain cartError, kesalahan dicatat di log dan dibalas 500 dengan pesan fallback.
+func writeCartError(w http.ResponseWriter, err error, fallback string) {
+	status := http.StatusInternalServerError
+	var cartErr *cartError
+	if errors.As(err, &cartErr) {
+		status = cartErr.Status
+	}
+
+	writeJSONResult(w, Result{Code: status, Message: cartErrorMessage(err, fallback)})
+}
+
+// writeJSONResult menulis Result sebagai JSON dengan status HTTP sama dengan Code
+func writeJSONResult(w http.ResponseWriter, res Result) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(res.Code)
+	err := json.NewEncoder(w).Encode(res)
+	if err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
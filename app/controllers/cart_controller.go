@@ -2,8 +2,8 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"math"
 	"net/http"
@@ -20,13 +20,26 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/gieart87/gotoko/app/core/checkout"
+	"github.com/gieart87/gotoko/app/core/money"
 	"github.com/gieart87/gotoko/app/core/session/auth"
 	"github.com/gieart87/gotoko/app/core/session/flash"
+	"github.com/gieart87/gotoko/app/core/shipping"
 	"github.com/gieart87/gotoko/app/models"
+	"github.com/gieart87/gotoko/app/policies"
 )
 
 // GetShoppingCartID mengembalikan ID cart yang tersimpan dalam sesi atau membuat ID baru jika tidak ada.
-func GetShoppingCartID(w http.ResponseWriter, r *http.Request) string {
+// Pelanggan yang sudah login selalu memakai keranjang miliknya sehingga isinya sama di setiap perangkat.
+func (server *Server) GetShoppingCartID(w http.ResponseWriter, r *http.Request) string {
+	if user := auth.CurrentUser(server.DB, w, r); user != nil {
+		cartID, err := server.attachShoppingCart(w, r, user)
+		if err == nil {
+			return cartID
+		}
+		log.Printf("Failed to attach cart to user %s: %v", user.ID, err)
+	}
+
 	// Mengambil sesi cart dari store dengan nama "sessionShoppingCart".
 	session, _ := store.Get(r, sessionShoppingCart)
 	// Jika "cart-id" tidak ada dalam sesi, buat ID baru dan simpan ke dalam sesi.
@@ -39,6 +52,85 @@ func GetShoppingCartID(w http.ResponseWriter, r *http.Request) string {
 	return fmt.Sprintf("%v", session.Values["cart-id"])
 }
 
+// attachShoppingCart memastikan pelanggan memiliki keranjang dan menyimpan ID-nya di sesi. Keranjang tamu
+// di sesi digabung ke keranjang pelanggan, atau langsung menjadi miliknya jika pelanggan belum punya keranjang.
+// Keranjang milik pelanggan lain, misalnya dari sesi sebelum logout, tidak pernah digabung.
+func (server *Server) attachShoppingCart(w http.ResponseWriter, r *http.Request, user *models.User) (string, error) {
+	session, _ := store.Get(r, sessionShoppingCart)
+	sessionCartID, _ := session.Values["cart-id"].(string)
+
+	var cartModel models.Cart
+	cart, err := cartModel.FindByUserID(server.DB, user.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	if cart != nil && cart.ID == sessionCartID {
+		return cart.ID, nil
+	}
+
+	var guestCart *models.Cart
+	if sessionCartID != "" {
+		existCart, err := cartModel.GetCart(server.DB, sessionCartID)
+		if err == nil && existCart.UserID == "" {
+			guestCart = existCart
+		}
+	}
+
+	if cart != nil {
+		if guestCart != nil {
+			err = cart.MergeCart(server.DB, guestCart)
+			if err != nil {
+				return "", err
+			}
+		}
+	} else {
+		candidate := guestCart
+		if candidate == nil {
+			candidate, err = cartModel.CreateCart(server.DB, uuid.New().String())
+			if err != nil {
+				return "", err
+			}
+		}
+
+		cart = candidate
+		assignErr := candidate.AssignUser(server.DB, user.ID)
+		if assignErr != nil {
+			// Permintaan lain dari pelanggan yang sama bisa lebih dulu membuat keranjangnya dan indeks unik user_id
+			// menolak keranjang kedua. Keranjang tersebut dibaca ulang lalu keranjang ini digabungkan ke sana.
+			cart, err = cartModel.FindByUserID(server.DB, user.ID)
+			if err != nil {
+				return "", assignErr
+			}
+			err = cart.MergeCart(server.DB, candidate)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	// Item tamu dihitung dengan harga tamu, hitung ulang dengan harga pelanggan
+	if guestCart != nil {
+		err = server.repriceCart(cart.ID, user)
+		if err != nil {
+			log.Printf("Failed to reprice cart %s: %v", cart.ID, err)
+		}
+	}
+
+	if sessionCartID != cart.ID {
+		session.Values["cart-id"] = cart.ID
+		_ = session.Save(r, w)
+	}
+
+	return cart.ID, nil
+}
+
+// forgetShoppingCart menghapus ID cart dari sesi saat logout agar keranjang pelanggan tidak terlihat oleh tamu berikutnya
+func forgetShoppingCart(w http.ResponseWriter, r *http.Request) {
+	session, _ := store.Get(r, sessionShoppingCart)
+	delete(session.Values, "cart-id")
+	_ = session.Save(r, w)
+}
+
 // ClearCart menghapus item dalam keranjang berdasarkan cartID.
 func ClearCart(db *gorm.DB, cartID string) error {
 	var cart models.Cart
@@ -70,20 +162,9 @@ func GetShoppingCart(db *gorm.DB, cartID string) (*models.Cart, error) {
 
 	// Menghitung total berat cart.
 	totalWeight := 0
-	productModel := models.Product{}
-	for _, cartItem := range updatedCart.CartItems { // Mengiterasi setiap item dalam cart.
-		// Mencari produk berdasarkan ProductID yang ada dalam cart item.
-		product, _ := productModel.FindByID(db, cartItem.ProductID)
-
-		// Mendapatkan berat produk dan menghitung berat total per item.
-		productWeight, _ := product.Weight.Float64()
-		ceilWeight := math.Ceil(productWeight) // Membulatkan berat produk ke atas.
-
-		// Menghitung berat total untuk item tersebut berdasarkan jumlahnya.
-		itemWeight := cartItem.Qty * int(ceilWeight)
-
+	for i := range updatedCart.CartItems { // Mengiterasi setiap item dalam cart.
 		// Menambahkan berat item ke total berat cart.
-		totalWeight += itemWeight
+		totalWeight += cartItemWeight(&updatedCart.CartItems[i])
 	}
 
 	// Menyimpan total berat ke dalam cart yang diperbarui.
@@ -93,6 +174,59 @@ func GetShoppingCart(db *gorm.DB, cartID string) (*models.Cart, error) {
 	return updatedCart, nil
 }
 
+// cartItemWeight menghitung berat item keranjang, berat produk dibulatkan ke atas lalu dikalikan jumlahnya.
+// Product item harus sudah dimuat, seperti hasil Cart.GetCart.
+func cartItemWeight(item *models.CartItem) int {
+	productWeight, _ := item.Product.Weight.Float64()
+
+	return item.Qty * int(math.Ceil(productWeight))
+}
+
+// repriceCart menghitung ulang harga setiap item keranjang dengan aturan harga yang berlaku,
+// dipanggil setiap kali isi keranjang berubah.
+func (server *Server) repriceCart(cartID string, user *models.User) error {
+	var cartModel models.Cart
+	cart, err := cartModel.GetCart(server.DB, cartID)
+	if err != nil {
+		return err
+	}
+
+	priceList, err := server.Pricing.PriceList(user)
+	if err != nil {
+		return err
+	}
+
+	productModel := models.Product{}
+	for _, item := range cart.CartItems {
+		product, err := productModel.FindByID(server.DB, item.ProductID)
+		if err != nil {
+			return err
+		}
+
+		// Satuan yang sudah dihapus tidak bisa dihitung harganya, item tersebut ditolak saat checkout
+		unit, ok := product.FindUnitForItem(item.ProductUnitID, item.Unit)
+		if !ok {
+			continue
+		}
+
+		tier, err := priceList.Quote(product, unit, item.Qty)
+		if err != nil {
+			return err
+		}
+
+		if tier.Price.Equal(item.Pricenew) && tier.Label == item.PriceTier && tier.FlashSaleID == item.FlashSaleID {
+			continue
+		}
+
+		_, err = cart.UpdateItemPrice(server.DB, item.ID, tier.Price, tier.Label, tier.FlashSaleID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Fungsi checkAWB untuk memeriksa dan menampilkan halaman cek resi berdasarkan cart yang ada.
 func (server *Server) checkAWB(w http.ResponseWriter, r *http.Request) {
 	// Membuat objek render baru untuk merender tampilan HTML menggunakan layout dan template.
@@ -105,7 +239,7 @@ func (server *Server) checkAWB(w http.ResponseWriter, r *http.Request) {
 	var cart *models.Cart
 
 	// Mendapatkan cartID dari sesi pengguna dan mengambil cart berdasarkan cartID.
-	cartID := GetShoppingCartID(w, r)
+	cartID := server.GetShoppingCartID(w, r)
 	cart, _ = GetShoppingCart(server.DB, cartID)
 
 	items, _ := GetCartItemsWithImages(server.DB, cartID)
@@ -132,7 +266,7 @@ func (server *Server) Track(w http.ResponseWriter, r *http.Request) {
 	var cart *models.Cart
 
 	// Mendapatkan cartID dari sesi pengguna dan mengambil cart berdasarkan cartID.
-	cartID := GetShoppingCartID(w, r)
+	cartID := server.GetShoppingCartID(w, r)
 	cart, _ = GetShoppingCart(server.DB, cartID)
 
 	items, _ := GetCartItemsWithImages(server.DB, cartID)
@@ -160,45 +294,13 @@ func (server *Server) CekResiHandler(w http.ResponseWriter, r *http.Request) {
 		r.ParseForm()
 		resiNumber := r.FormValue("resi_number") // Mengambil nilai dari input "resi_number"
 
-		// Menyusun URL API untuk cek resi berdasarkan nomor resi yang diterima
-		url := fmt.Sprintf("https://api.biteship.com/v1/trackings/%s", resiNumber)
-		req, err := http.NewRequest("GET", url, nil) // Membuat request GET ke API
+		// Mengambil data pelacakan dari provider pengiriman
+		trackingData, err := server.Shipping.Track(resiNumber)
 		if err != nil {
-			// Menangani error jika request gagal dibuat
-			http.Error(w, "Gagal membuat request", http.StatusInternalServerError)
-			return
-		}
-
-		// Menambahkan header Authorization untuk autentikasi ke API
-		req.Header.Set("Authorization", "Bearer "+os.Getenv("API_BITESHIP"))
-
-		// Membuat client HTTP untuk mengirim request
-		client := &http.Client{}
-		resp, err := client.Do(req) // Mengirim request ke API
-		if err != nil {
-			// Menangani error jika API gagal diakses
+			// Menangani error jika provider gagal diakses
 			http.Error(w, "Gagal memanggil API", http.StatusInternalServerError)
 			return
 		}
-		defer resp.Body.Close() // Menutup body response setelah selesai digunakan
-
-		// Membaca isi body response dari API
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			// Menangani error jika membaca response gagal
-			http.Error(w, "Gagal membaca response", http.StatusInternalServerError)
-			return
-		}
-
-		// Mendeklarasikan variabel untuk menyimpan hasil parsing JSON
-		var trackingData models.TrackingResponse
-		// Mem-parsing body response menjadi objek JSON
-		err = json.Unmarshal(body, &trackingData)
-		if err != nil {
-			// Menangani error jika parsing JSON gagal
-			http.Error(w, "Gagal parsing data JSON", http.StatusInternalServerError)
-			return
-		}
 
 		// Merender template cek_resi dengan data hasil tracking
 		_ = render.HTML(w, http.StatusOK, "cek_resi", map[string]interface{}{
@@ -224,7 +326,7 @@ func (server *Server) GetCart(w http.ResponseWriter, r *http.Request) {
 	var cart *models.Cart
 
 	// Mendapatkan ID keranjang belanja dari sesi pengguna atau cookie.
-	cartID := GetShoppingCartID(w, r)
+	cartID := server.GetShoppingCartID(w, r)
 	// Mengambil data keranjang belanja dari database berdasarkan cartID.
 	cart, _ = GetShoppingCart(server.DB, cartID)
 
@@ -232,102 +334,265 @@ func (server *Server) GetCart(w http.ResponseWriter, r *http.Request) {
 
 	// Mengirimkan data ke template "cart" dan merender halaman.
 	_ = render.HTML(w, http.StatusOK, "cart", map[string]interface{}{
-		"cart":    cart,                              // Menampilkan data keranjang.
-		"items":   items,                             // Menampilkan item dalam keranjang.
-		"success": flash.GetFlash(w, r, "success"),   // Menampilkan pesan sukses (jika ada).
-		"error":   flash.GetFlash(w, r, "error"),     // Menampilkan pesan error (jika ada).
-		"user":    auth.CurrentUser(server.DB, w, r), // Menampilkan data pengguna yang sedang login.
+		"cart":       cart,                              // Menampilkan data keranjang.
+		"items":      items,                             // Menampilkan item dalam keranjang.
+		"taxSetting": models.GetTaxSetting(),            // Mode harga (termasuk/belum termasuk PPN).
+		"changes":    getCartChanges(w, r),              // Perubahan keranjang yang ditemukan saat checkout.
+		"success":    flash.GetFlash(w, r, "success"),   // Menampilkan pesan sukses (jika ada).
+		"error":      flash.GetFlash(w, r, "error"),     // Menampilkan pesan error (jika ada).
+		"user":       auth.CurrentUser(server.DB, w, r), // Menampilkan data pengguna yang sedang login.
 	})
 }
 
+// setCartChanges menyimpan perubahan keranjang dari checkout di flash sesi agar ditampilkan di halaman keranjang
+func setCartChanges(w http.ResponseWriter, r *http.Request, changes []checkout.Change) {
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		log.Printf("Failed to encode cart changes: %v", err)
+		return
+	}
+
+	flash.SetFlash(w, r, "cart-changes", string(encoded))
+}
+
+// getCartChanges mengambil perubahan keranjang yang disimpan setCartChanges
+func getCartChanges(w http.ResponseWriter, r *http.Request) []checkout.Change {
+	var changes []checkout.Change
+	for _, encoded := range flash.GetFlash(w, r, "cart-changes") {
+		var decoded []checkout.Change
+		if err := json.Unmarshal([]byte(encoded), &decoded); err == nil {
+			changes = append(changes, decoded...)
+		}
+	}
+
+	return changes
+}
+
 // Fungsi ini menambahkan item ke dalam keranjang belanja.
 func (server *Server) AddItemToCart(w http.ResponseWriter, r *http.Request) {
-	// Mendapatkan ID produk dan kuantitas dari form.
+	// Mendapatkan ID produk, kuantitas dan satuan dari form.
 	productID := r.FormValue("product_id")
 	qty, _ := strconv.Atoi(r.FormValue("qty"))
 	unit := r.FormValue("unit")
 
-	// Mencari produk berdasarkan ID.
+	// Mendapatkan cartID dan mengambil data keranjang belanja.
+	cartID := server.GetShoppingCartID(w, r)
+	cart, _ := GetShoppingCart(server.DB, cartID)
+
+	product, err := server.addCartItem(cart, auth.CurrentUser(server.DB, w, r), productID, unit, qty)
+	if err != nil {
+		// Kesalahan ditampilkan di halaman produk, atau di daftar produk jika produknya tidak ditemukan.
+		redirectURL := "/products"
+		if product != nil {
+			redirectURL += "/" + product.Slug
+		}
+		flash.SetFlash(w, r, "error", cartErrorMessage(err, "Gagal menambahkan item ke keranjang"))
+		http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+		return
+	}
+
+	// Set flash message sukses dan redirect ke halaman keranjang belanja.
+	flash.SetFlash(w, r, "success", "Item berhasil ditambahkan")
+	http.Redirect(w, r, "/carts", http.StatusSeeOther)
+}
+
+// cartError adalah kesalahan perubahan keranjang karena isi permintaan, misalnya stok tidak cukup.
+// Message aman ditampilkan ke pelanggan dan Status dipakai sebagai status HTTP API keranjang.
+type cartError struct {
+	Status  int
+	Message string
+}
+
+func (e *cartError) Error() string {
+	return e.Message
+}
+
+// cartErrorMessage mengembalikan pesan cartError, atau fallback untuk kesalahan lain yang hanya dicatat di log
+func cartErrorMessage(err error, fallback string) string {
+	var cartErr *cartError
+	if errors.As(err, &cartErr) {
+		return cartErr.Message
+	}
+
+	log.Printf("Cart update failed: %v", err)
+	return fallback
+}
+
+// addCartItem menambahkan qty satuan produk ke keranjang dengan harga sesuai aturan harga pelanggan, lalu
+// menghitung ulang harga keranjang. Produk dikembalikan walaupun gagal agar pemanggil bisa kembali ke halaman produk.
+func (server *Server) addCartItem(cart *models.Cart, user *models.User, productID string, unit string, qty int) (*models.Product, error) {
 	productModel := models.Product{}
 	product, err := productModel.FindByID(server.DB, productID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, &cartError{Status: http.StatusNotFound, Message: "Produk tidak ditemukan"}
+	}
 	if err != nil {
-		// Jika produk tidak ditemukan, redirect ke halaman produk.
-		http.Redirect(w, r, "/products/"+product.Slug, http.StatusSeeOther)
-		return
+		return nil, err
 	}
-	// Mengecek apakah kuantitas yang diminta lebih besar dari stok yang tersedia.
-	if qty > product.Stock {
-		// Jika stok tidak mencukupi, set flash message error dan redirect ke halaman produk.
-		flash.SetFlash(w, r, "error", "Stok tidak mencukupi")
-		http.Redirect(w, r, "/products/"+product.Slug, http.StatusSeeOther)
-		return
+
+	if !product.IsActive() {
+		return product, &cartError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Produk %s sedang tidak dijual", product.Name)}
 	}
-	// Tentukan harga berdasarkan satuan dan kuantitas
-	if qty > 2 {
-		// Harga grosir untuk qty > 2
-		if unit == product.SATUAN1 {
-			product.Price = product.HJ1
-		} else if unit == product.SATUAN2 {
-			product.Price = product.HJ2
-		} else if unit == product.SATUAN3 {
-			product.Price = product.HJ3
-		} else {
-			http.Error(w, "Invalid unit", http.StatusBadRequest)
-			return
+	if qty < 1 {
+		return product, &cartError{Status: http.StatusUnprocessableEntity, Message: "Jumlah minimal 1"}
+	}
+	productUnit, ok := product.FindUnit(unit)
+	if !ok {
+		return product, &cartError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Satuan %s tidak tersedia untuk produk %s", unit, product.Name)}
+	}
+
+	// Harga mengikuti aturan harga yang berlaku untuk pelanggan, satuan dan kuantitas
+	priceList, err := server.Pricing.PriceList(user)
+	if err != nil {
+		return product, err
+	}
+	tier, err := priceList.Quote(product, productUnit, qty)
+	if err != nil {
+		return product, err
+	}
+
+	// Harga flash sale hanya berlaku selama kuotanya cukup, termasuk satuan yang sama yang sudah ada di keranjang.
+	if sale := priceList.FlashSale(product, productUnit); sale != nil && sale.Quota > 0 {
+		cartQty := qty
+		for _, item := range cart.CartItems {
+			if item.ProductID == product.ID && item.Unit == unit {
+				cartQty += item.Qty
+			}
 		}
-	} else {
-		// Harga eceran untuk qty <= 2
-		if unit == product.SATUAN1 {
-			product.Price = product.HJ2_1
-		} else if unit == product.SATUAN2 {
-			product.Price = product.HJ2_2
-		} else if unit == product.SATUAN3 {
-			product.Price = product.HJ2_3
-		} else {
-			http.Error(w, "Invalid unit", http.StatusBadRequest)
-			return
+		if !sale.Allows(cartQty) {
+			message := fmt.Sprintf("Sisa kuota flash sale %s tinggal %d %s", sale.Name, sale.Remaining(), sale.UnitName)
+			return product, &cartError{Status: http.StatusUnprocessableEntity, Message: message}
 		}
 	}
 
-	// Mendapatkan cartID dan mengambil data keranjang belanja.
-	var cart *models.Cart
-	cartID := GetShoppingCartID(w, r)
-	cart, _ = GetShoppingCart(server.DB, cartID)
-	// Menambahkan item ke dalam keranjang.
-	_, err = cart.AddItem(server.DB, models.CartItem{
-		ProductID: productID,
-		Qty:       qty,
-		Unit:      unit,                         // Simpan unit yang dipilih
-		Pricenew:  int(product.Price.IntPart()), // Gunakan harga yang sudah di-set
-	})
+	newItem := models.CartItem{
+		ProductID:     productID,
+		Qty:           qty,
+		Unit:          unit,
+		ProductUnitID: productUnit.ID,
+		PriceTier:     tier.Label,
+		FlashSaleID:   tier.FlashSaleID,
+		Pricenew:      tier.Price,
+	}
+
+	// Mengecek stok dalam satuan dasar, termasuk item produk yang sama yang sudah ada di keranjang.
+	err = models.CheckStock(server.DB, append(cart.CartItems, newItem))
 	if err != nil {
-		// Jika ada error, redirect ke halaman produk.
-		http.Redirect(w, r, "/products/"+product.Slug, http.StatusSeeOther)
+		return product, stockCartError(err)
 	}
 
-	// Set flash message sukses dan redirect ke halaman keranjang belanja.
-	flash.SetFlash(w, r, "success", "Item berhasil ditambahkan")
-	http.Redirect(w, r, "/carts", http.StatusSeeOther)
+	_, err = cart.AddItem(server.DB, newItem)
+	if err != nil {
+		return product, err
+	}
+
+	// Kuantitas item bisa bertambah karena digabung dengan item yang sama, hitung ulang tingkat harganya.
+	err = server.repriceCart(cart.ID, user)
+	if err != nil {
+		log.Printf("Failed to reprice cart %s: %v", cart.ID, err)
+	}
+
+	return product, nil
+}
+
+// setCartItemQty mengubah qty satu item keranjang setelah memastikan stoknya cukup, lalu menghitung ulang harga keranjang
+func (server *Server) setCartItemQty(cart *models.Cart, user *models.User, itemID string, qty int) error {
+	if qty < 1 {
+		return &cartError{Status: http.StatusUnprocessableEntity, Message: "Jumlah minimal 1"}
+	}
+
+	_, err := server.findCartItem(cart, itemID)
+	if err != nil {
+		return err
+	}
+
+	items := make([]models.CartItem, len(cart.CartItems))
+	for i, item := range cart.CartItems {
+		items[i] = item
+		if item.ID == itemID {
+			items[i].Qty = qty
+		}
+	}
+
+	err = models.CheckStock(server.DB, items)
+	if err != nil {
+		return stockCartError(err)
+	}
+
+	_, err = cart.UpdateItemQty(server.DB, itemID, qty)
+	if err != nil {
+		return err
+	}
+
+	// Tingkat harga bisa berubah setelah kuantitas diubah, misalnya dari eceran ke grosir.
+	err = server.repriceCart(cart.ID, user)
+	if err != nil {
+		log.Printf("Failed to reprice cart %s: %v", cart.ID, err)
+	}
+
+	return nil
+}
+
+// findCartItem mencari item milik keranjang cart. Item yang tidak ada dan item keranjang lain sama-sama dibalas 404.
+func (server *Server) findCartItem(cart *models.Cart, itemID string) (*models.CartItem, error) {
+	itemModel := models.CartItem{}
+	item, err := itemModel.FindByID(server.DB, itemID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !policies.CanModifyCartItem(cart, item)) {
+		return nil, &cartError{Status: http.StatusNotFound, Message: "Item keranjang tidak ditemukan"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// stockCartError mengubah hasil CheckStock yang gagal karena stok tidak cukup menjadi cartError
+func stockCartError(err error) error {
+	var stockErr *models.InsufficientStockError
+	if errors.As(err, &stockErr) {
+		return &cartError{Status: http.StatusUnprocessableEntity, Message: stockErr.Error()}
+	}
+
+	return err
 }
 
 // Fungsi ini untuk memperbarui kuantitas item dalam keranjang belanja.
 func (server *Server) UpdateCart(w http.ResponseWriter, r *http.Request) {
 	// Mendapatkan ID keranjang belanja dari sesi pengguna.
-	cartID := GetShoppingCartID(w, r)
+	cartID := server.GetShoppingCartID(w, r)
 	cart, _ := GetShoppingCart(server.DB, cartID)
 
-	// Mengupdate kuantitas setiap item dalam keranjang berdasarkan form input.
-	for _, item := range cart.CartItems {
-		// Mengambil kuantitas dari form.
-		qty, _ := strconv.Atoi(r.FormValue(item.ID))
+	// Mengambil kuantitas baru setiap item dari form input.
+	quantities := map[string]int{}
+	items := make([]models.CartItem, len(cart.CartItems))
+	for i, item := range cart.CartItems {
+		items[i] = item
 
 		// Skip jika qty 0 atau negatif
-		if qty <= 0 {
+		qty, _ := strconv.Atoi(r.FormValue(item.ID))
+		if qty > 0 {
+			quantities[item.ID] = qty
+			items[i].Qty = qty
+		}
+	}
+
+	// Mengecek stok dalam satuan dasar sebelum ada kuantitas yang diubah.
+	err := models.CheckStock(server.DB, items)
+	if err != nil {
+		flash.SetFlash(w, r, "error", err.Error())
+		http.Redirect(w, r, "/carts", http.StatusSeeOther)
+		return
+	}
+
+	// Mengupdate kuantitas setiap item dalam keranjang.
+	for _, item := range cart.CartItems {
+		qty, ok := quantities[item.ID]
+		if !ok {
 			continue
 		}
 
-		// Memperbarui kuantitas item dalam keranjang tanpa mengubah harga
-		// Gunakan harga yang sudah tersimpan di item.Pricenew
+		// Memperbarui kuantitas item, harganya dihitung ulang oleh repriceCart di bawah
 		_, err := cart.UpdateItemQty(server.DB, item.ID, qty)
 		if err != nil {
 			http.Redirect(w, r, "/carts", http.StatusSeeOther)
@@ -335,10 +600,65 @@ func (server *Server) UpdateCart(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Tingkat harga bisa berubah setelah kuantitas diubah, misalnya dari eceran ke grosir.
+	err = server.repriceCart(cart.ID, auth.CurrentUser(server.DB, w, r))
+	if err != nil {
+		log.Printf("Failed to reprice cart %s: %v", cart.ID, err)
+	}
+
 	// Setelah selesai mengupdate, redirect kembali ke halaman keranjang.
 	http.Redirect(w, r, "/carts", http.StatusSeeOther)
 }
 
+// RedeemVoucher memasang kode voucher pada keranjang setelah memastikan voucher berlaku untuk
+// isi keranjang dan belum melewati batas pemakaian pelanggan.
+func (server *Server) RedeemVoucher(w http.ResponseWriter, r *http.Request) {
+	cartID := server.GetShoppingCartID(w, r)
+	cart, _ := GetShoppingCart(server.DB, cartID)
+
+	code := models.NormalizeVoucherCode(r.FormValue("code"))
+	if code == "" {
+		flash.SetFlash(w, r, "error", "Masukkan kode voucher")
+		http.Redirect(w, r, "/carts", http.StatusSeeOther)
+		return
+	}
+
+	voucherModel := models.Voucher{}
+	voucher, err := voucherModel.FindByCode(server.DB, code)
+	if err != nil {
+		flash.SetFlash(w, r, "error", "Voucher "+code+" tidak ditemukan")
+		http.Redirect(w, r, "/carts", http.StatusSeeOther)
+		return
+	}
+
+	user := auth.CurrentUser(server.DB, w, r)
+	err = voucher.CheckCart(server.DB, cart, user.ID)
+	if err == nil {
+		err = cart.ApplyVoucher(server.DB, voucher)
+	}
+	if err != nil {
+		flash.SetFlash(w, r, "error", err.Error())
+		http.Redirect(w, r, "/carts", http.StatusSeeOther)
+		return
+	}
+
+	flash.SetFlash(w, r, "success", "Voucher "+voucher.Code+" berhasil dipakai")
+	http.Redirect(w, r, "/carts", http.StatusSeeOther)
+}
+
+// RemoveVoucher melepas voucher dari keranjang
+func (server *Server) RemoveVoucher(w http.ResponseWriter, r *http.Request) {
+	cartID := server.GetShoppingCartID(w, r)
+	cart, _ := GetShoppingCart(server.DB, cartID)
+
+	err := cart.RemoveVoucher(server.DB)
+	if err != nil {
+		flash.SetFlash(w, r, "error", "Gagal melepas voucher")
+	}
+
+	http.Redirect(w, r, "/carts", http.StatusSeeOther)
+}
+
 // Fungsi ini untuk menghapus item dari keranjang belanja berdasarkan ID.
 func (server *Server) RemoveItemByID(w http.ResponseWriter, r *http.Request) {
 	// Mengambil parameter ID item yang ingin dihapus dari URL.
@@ -348,10 +668,16 @@ func (server *Server) RemoveItemByID(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/carts", http.StatusSeeOther)
 	}
 	// Mendapatkan cartID dan data keranjang.
-	cartID := GetShoppingCartID(w, r)
+	cartID := server.GetShoppingCartID(w, r)
 	cart, _ := GetShoppingCart(server.DB, cartID)
+	// Item keranjang lain dibalas 404 seperti item yang tidak ada.
+	_, err := server.findCartItem(cart, vars["id"])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
 	// Menghapus item dari keranjang berdasarkan ID.
-	err := cart.RemoveItemByID(server.DB, vars["id"])
+	err = cart.RemoveItemByID(server.DB, vars["id"])
 	if err != nil {
 		// Jika ada error, redirect kembali ke halaman keranjang.
 		http.Redirect(w, r, "/carts", http.StatusSeeOther)
@@ -393,7 +719,7 @@ func (server *Server) CalculateShippingBiteship(w http.ResponseWriter, r *http.R
 	}
 
 	// Mengambil ID keranjang belanja dari sesi pengguna atau cookie.
-	cartID := GetShoppingCartID(w, r)
+	cartID := server.GetShoppingCartID(w, r)
 
 	// Mengambil informasi keranjang belanja berdasarkan cartID.
 	cart, err := GetShoppingCart(server.DB, cartID)
@@ -414,12 +740,19 @@ func (server *Server) CalculateShippingBiteship(w http.ResponseWriter, r *http.R
 			return
 		}
 
+		var destinationCoordinate shipping.Coordinate
+		destinationCoordinate, err = shipping.ParseCoordinate(latitude, longitude)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		// Jika jenis kurir adalah "instant", hitung biaya pengiriman menggunakan metode instant
-		shippingFeeOptions, err = server.CalculateShippingFeeBiteshipInstant(ShippingFeeParams{
-			Origin:      latitude,         // Gunakan latitude dari form
-			Destination: longitude,        // Gunakan longitude dari form
-			Weight:      cart.TotalWeight, // Berat total dari keranjang
-			Couriers:    courier,          // Kurir yang digunakan
+		shippingFeeOptions, err = server.Shipping.InstantRates(shipping.RateParams{
+			OriginCoordinate:      shipping.StoreCoordinate, // Pengiriman instan selalu dari toko
+			DestinationCoordinate: destinationCoordinate,    // Koordinat dari form
+			Weight:                cart.TotalWeight,         // Berat total dari keranjang
+			Couriers:              courier,                  // Kurir yang digunakan
 		})
 		log.Printf("Instant delivery calculation with lat: %s, lng: %s", latitude, longitude)
 	} else if cour_type == "pickup" {
@@ -427,7 +760,7 @@ func (server *Server) CalculateShippingBiteship(w http.ResponseWriter, r *http.R
 		shippingFeeOptions = []models.Pricing{
 			{
 				CourierName: "Pickup",
-				Price:       0,
+				Price:       money.Zero,
 			},
 		}
 	} else {
@@ -436,7 +769,7 @@ func (server *Server) CalculateShippingBiteship(w http.ResponseWriter, r *http.R
 		// Konversi city_id user ke Biteship area ID untuk regular delivery
 		destinationAreaID := convertCityIDToBiteshipAreaID(destination)
 		// Hitung biaya pengiriman menggunakan metode biasa
-		shippingFeeOptions, err = server.CalculateShippingFeeBiteship(ShippingFeeParams{
+		shippingFeeOptions, err = server.Shipping.Rates(shipping.RateParams{
 			Origin:      default_location,  // Origin tetap default (toko)
 			Destination: destinationAreaID, // Destination menggunakan area ID yang benar
 			Weight:      cart.TotalWeight,  // Berat total dari keranjang
@@ -524,7 +857,7 @@ func (server *Server) ApplyShipping(w http.ResponseWriter, r *http.Request) {
 	shippingPackage := r.FormValue("shipping_package")
 
 	// Mendapatkan ID keranjang belanja pengguna.
-	cartID := GetShoppingCartID(w, r)
+	cartID := server.GetShoppingCartID(w, r)
 
 	// Mengambil data keranjang belanja dari database.
 	cart, _ := GetShoppingCart(server.DB, cartID)
@@ -554,11 +887,18 @@ func (server *Server) ApplyShipping(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		shippingFeeOptions, err = server.CalculateShippingFeeBiteshipInstant(ShippingFeeParams{
-			Origin:      latitudeStr,  // Gunakan latitude dari form
-			Destination: longitudeStr, // Gunakan longitude dari form
-			Weight:      cart.TotalWeight,
-			Couriers:    courier,
+		var destinationCoordinate shipping.Coordinate
+		destinationCoordinate, err = shipping.ParseCoordinate(latitudeStr, longitudeStr)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		shippingFeeOptions, err = server.Shipping.InstantRates(shipping.RateParams{
+			OriginCoordinate:      shipping.StoreCoordinate, // Pengiriman instan selalu dari toko
+			DestinationCoordinate: destinationCoordinate,    // Koordinat dari form
+			Weight:                cart.TotalWeight,
+			Couriers:              courier,
 		})
 		log.Printf("Apply instant delivery with lat: %s, lng: %s", latitudeStr, longitudeStr)
 	} else if cour_type == "pickup" {
@@ -566,14 +906,14 @@ func (server *Server) ApplyShipping(w http.ResponseWriter, r *http.Request) {
 		shippingFeeOptions = []models.Pricing{
 			{
 				CourierName: "Pickup",
-				Price:       0,
+				Price:       money.Zero,
 			},
 		}
 	} else {
 		log.Printf("Apply regular delivery for: %s", cour_type)
 		// Konversi city_id user ke Biteship area ID
 		destinationAreaID := convertCityIDToBiteshipAreaID(destination)
-		shippingFeeOptions, err = server.CalculateShippingFeeBiteship(ShippingFeeParams{
+		shippingFeeOptions, err = server.Shipping.Rates(shipping.RateParams{
 			Origin:      default_location,  // Origin tetap default (toko)
 			Destination: destinationAreaID, // Destination menggunakan area ID yang benar
 			Weight:      cart.TotalWeight,
@@ -600,21 +940,17 @@ func (server *Server) ApplyShipping(w http.ResponseWriter, r *http.Request) {
 
 	// Struktur respons untuk data pengiriman yang diterapkan.
 	type ApplyShippingResponse struct {
-		TotalOrder  decimal.Decimal        `json:"total_order"`
-		ShippingFee decimal.Decimal        `json:"shipping_fee"`
-		GrandTotal  decimal.Decimal        `json:"grand_total"`
+		TotalOrder  money.Money            `json:"total_order"`
+		ShippingFee money.Money            `json:"shipping_fee"`
+		GrandTotal  money.Money            `json:"grand_total"`
 		TotalWeight decimal.Decimal        `json:"total_weight"`
 		Origin      map[string]interface{} `json:"origin"`
 		Destination map[string]interface{} `json:"destination"`
 		CourierInfo map[string]interface{} `json:"courier_info"`
 	}
 
-	// Menghitung total biaya termasuk biaya pengiriman.
-	var grandTotal float64
-
-	cartGrandTotal, _ := cart.GrandTotal.Float64()
-	shippingFee := float64(selectedShipping.Price)
-	grandTotal = cartGrandTotal + shippingFee
+	// Menghitung total biaya termasuk biaya pengiriman, sama seperti total order saat checkout.
+	grandTotal := orderGrandTotal(cart, selectedShipping.Price)
 
 	// Siapkan informasi area untuk response - harus konsisten dengan CalculateShippingBiteship
 	var originInfo, destinationInfo map[string]interface{}
@@ -664,8 +1000,8 @@ func (server *Server) ApplyShipping(w http.ResponseWriter, r *http.Request) {
 	// Menyiapkan respons dengan data yang dihitung.
 	applyShippingResponse := ApplyShippingResponse{
 		TotalOrder:  cart.GrandTotal,
-		ShippingFee: decimal.NewFromInt(int64(selectedShipping.Price)),
-		GrandTotal:  decimal.NewFromFloat(grandTotal),
+		ShippingFee: selectedShipping.Price,
+		GrandTotal:  grandTotal,
 		TotalWeight: decimal.NewFromInt(int64(cart.TotalWeight)),
 		Origin:      originInfo,
 		Destination: destinationInfo,
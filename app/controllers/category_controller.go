@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/unrolled/render"
+
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/models"
+)
+
+// GetCategoryBySlug menampilkan produk pada sebuah kategori beserta sub kategorinya
+func (server *Server) GetCategoryBySlug(w http.ResponseWriter, r *http.Request) {
+	render := render.New(render.Options{
+		Layout:     "layout",
+		Extensions: []string{".html", ".tmpl"},
+	})
+
+	vars := mux.Vars(r)
+
+	categoryModel := models.Category{}
+	category, err := categoryModel.FindBySlug(server.DB, vars["slug"])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page <= 0 {
+		page = 1
+	}
+	perPage := 100
+
+	products, totalRows, err := category.GetProducts(server.DB, perPage, page)
+	if err != nil {
+		http.Error(w, "Gagal mengambil produk", http.StatusInternalServerError)
+		return
+	}
+
+	ancestors, err := category.GetAncestors(server.DB)
+	if err != nil {
+		http.Error(w, "Gagal mengambil kategori", http.StatusInternalServerError)
+		return
+	}
+
+	categories, err := categoryModel.GetCategoryTree(server.DB)
+	if err != nil {
+		http.Error(w, "Gagal mengambil kategori", http.StatusInternalServerError)
+		return
+	}
+
+	// Stok yang ditampilkan adalah stok yang belum ditahan order lain, beserta harga flash sale per ID produk
+	user := auth.CurrentUser(server.DB, w, r)
+	flashSales, err := server.productListing(user, *products)
+	if err != nil {
+		http.Error(w, "Gagal mengambil produk", http.StatusInternalServerError)
+		return
+	}
+
+	pagination, _ := GetPaginationLinks(server.AppConfig, PaginationParams{
+		Path:        "categories/" + category.Slug,
+		TotalRows:   int32(totalRows),
+		PerPage:     int32(perPage),
+		CurrentPage: int32(page),
+	})
+
+	_ = render.HTML(w, http.StatusOK, "products", map[string]interface{}{
+		"products":          products,
+		"flashSales":        flashSales,
+		"pagination":        pagination,
+		"user":              user,
+		"categories":        categories,
+		"category":          category,
+		"categoryAncestors": ancestors,
+	})
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/gieart87/gotoko/app/core/money"
+	"github.com/gieart87/gotoko/app/core/pricing"
+	"github.com/gieart87/gotoko/app/models"
+	"github.com/gieart87/gotoko/database/migrations"
+)
+
+// newTestServer membuat server dengan database SQLite sementara dan direktori kerja di root repo agar template ditemukan
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	if err := os.Chdir(filepath.Join("..", "..")); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(dir) })
+
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	db, err := gorm.Open(sqlite.Dialector{DriverName: "sqlite", DSN: dsn}, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := migrations.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	return &Server{
+		DB:        db,
+		AppConfig: &AppConfig{AppName: "GoToko", AppURL: "http://localhost"},
+		Pricing:   pricing.NewEngine(db),
+	}
+}
+
+func TestGetCategoryBySlug(t *testing.T) {
+	tests := []struct {
+		name      string
+		flashSale bool
+		held      int
+		want      []string
+	}{
+		{"produk tanpa flash sale", false, 0, []string{"Beras Premium", "Stok: 10 Karung"}},
+		{"produk dengan flash sale", true, 0, []string{"Beras Premium", "Stok: 10 Karung", "Rp 9000 / Karung"}},
+		{"stok ditahan order belum dibayar", false, 4, []string{"Beras Premium", "Stok: 6 Karung"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t)
+
+			category := models.Category{ID: "C1", Name: "Sembako", Slug: "sembako"}
+			product := models.Product{ID: "P1", Name: "Beras Premium", Slug: "beras-premium", Stock: 10, Status: 1,
+				Categories: []models.Category{category},
+				Units:      []models.ProductUnit{{Name: "Karung", Conversion: 1, WholesalePrice: decimal.NewFromInt(10000)}}}
+			if err := server.DB.Create(&product).Error; err != nil {
+				t.Fatalf("create product: %v", err)
+			}
+			if tt.flashSale {
+				sale := models.FlashSale{Name: "Promo", ProductID: product.ID, UnitName: "Karung", Price: money.FromInt(9000),
+					StartsAt: time.Now().Add(-time.Hour), EndsAt: time.Now().Add(time.Hour), Status: 1}
+				if err := server.DB.Create(&sale).Error; err != nil {
+					t.Fatalf("create flash sale: %v", err)
+				}
+			}
+
+			if tt.held > 0 {
+				reservation := models.StockReservation{OrderID: "O1", ProductID: product.ID, Qty: tt.held,
+					Status: models.StockReservationHeld, ExpiresAt: time.Now().Add(time.Hour)}
+				if err := server.DB.Create(&reservation).Error; err != nil {
+					t.Fatalf("create reservation: %v", err)
+				}
+			}
+
+			req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/categories/sembako", nil), map[string]string{"slug": "sembako"})
+			rec := httptest.NewRecorder()
+			server.GetCategoryBySlug(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, body: %s", rec.Code, rec.Body.String())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Errorf("halaman kategori tidak memuat %q", want)
+				}
+			}
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package controllers
 
 import (
-	"database/sql"
 	"errors"
 	"fmt"
 	"log"
@@ -12,17 +11,20 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/unrolled/render"
+	"gorm.io/gorm"
 
 	"github.com/google/uuid"
-	"github.com/midtrans/midtrans-go"
-	"github.com/midtrans/midtrans-go/snap"
 
 	"github.com/gieart87/gotoko/app/consts"
+	"github.com/gieart87/gotoko/app/core/checkout"
+	"github.com/gieart87/gotoko/app/core/money"
+	"github.com/gieart87/gotoko/app/core/payment"
 	"github.com/gieart87/gotoko/app/core/session/auth"
 	"github.com/gieart87/gotoko/app/core/session/flash"
+	"github.com/gieart87/gotoko/app/core/shipping"
 
 	"github.com/gieart87/gotoko/app/models"
-	"github.com/shopspring/decimal"
+	"github.com/gieart87/gotoko/app/policies"
 )
 
 type CheckoutRequest struct {
@@ -34,7 +36,7 @@ type CheckoutRequest struct {
 type ShippingFee struct {
 	Courier     string
 	PackageName string
-	Fee         float64
+	Fee         money.Money
 }
 
 type ShippingAddress struct {
@@ -63,11 +65,54 @@ func (server *Server) Checkout(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/carts", http.StatusSeeOther)
 		return
 	}
-	log.Printf("Shipping cost calculated: %f", shippingCost)
+	log.Printf("Shipping cost calculated: %s", shippingCost)
 
-	cartID := GetShoppingCartID(w, r)
+	cartID := server.GetShoppingCartID(w, r)
 	cart, _ := GetShoppingCart(server.DB, cartID)
 
+	// Produk, stok dan harga bisa berubah sejak item dimasukkan ke keranjang, misalnya setelah import harga mingguan.
+	// Keranjang diperbarui dan pelanggan harus melihat perubahannya sebelum checkout diulang.
+	changes, err := checkout.Validate(server.DB, server.Pricing, cart, user)
+	if err != nil {
+		log.Printf("Cart validation failed: %v", err)
+		flash.SetFlash(w, r, "error", "Proses checkout gagal")
+		http.Redirect(w, r, "/carts", http.StatusSeeOther)
+		return
+	}
+	if len(changes) > 0 {
+		setCartChanges(w, r, changes)
+		flash.SetFlash(w, r, "error", "Keranjang berubah sejak terakhir dilihat. Periksa perubahannya lalu lakukan checkout kembali.")
+		http.Redirect(w, r, "/carts", http.StatusSeeOther)
+		return
+	}
+
+	// Flash sale bisa berakhir atau kuotanya habis sejak item dimasukkan, keranjang dihitung ulang dengan harga normal
+	err = models.CheckFlashSales(server.DB, cart.CartItems)
+	if err != nil {
+		log.Printf("Flash sale check failed: %v", err)
+		if err := server.repriceCart(cart.ID, user); err != nil {
+			log.Printf("Failed to reprice cart %s: %v", cart.ID, err)
+		}
+		flash.SetFlash(w, r, "error", "Proses checkout gagal: "+err.Error())
+		http.Redirect(w, r, "/carts", http.StatusSeeOther)
+		return
+	}
+
+	// Batas pemakaian voucher dicek ulang untuk pelanggan ini karena bisa habis sejak voucher dipasang
+	if cart.VoucherCode != "" {
+		voucherModel := models.Voucher{}
+		voucher, err := voucherModel.FindByCode(server.DB, cart.VoucherCode)
+		if err == nil {
+			err = voucher.CheckCart(server.DB, cart, user.ID)
+		}
+		if err != nil {
+			log.Printf("Voucher check failed: %v", err)
+			flash.SetFlash(w, r, "error", "Proses checkout gagal: "+err.Error())
+			http.Redirect(w, r, "/carts", http.StatusSeeOther)
+			return
+		}
+	}
+
 	checkoutRequest := &CheckoutRequest{
 		Cart: cart,
 		ShippingFee: &ShippingFee{
@@ -90,7 +135,22 @@ func (server *Server) Checkout(w http.ResponseWriter, r *http.Request) {
 	order, err := server.SaveOrder(user, checkoutRequest)
 	if err != nil {
 		log.Printf("Failed to save order: %v", err)
-		flash.SetFlash(w, r, "error", "Proses checkout gagal")
+		message := "Proses checkout gagal"
+		// Stok terakhir atau kuota voucher bisa sudah dipakai checkout lain sejak pengecekan di atas
+		var stockErr *models.InsufficientStockError
+		var voucherErr *models.VoucherUsageError
+		var flashSaleErr *models.FlashSaleError
+		if errors.As(err, &stockErr) {
+			message += ": " + stockErr.Error()
+		} else if errors.As(err, &voucherErr) {
+			message += ": " + voucherErr.Error()
+		} else if errors.As(err, &flashSaleErr) {
+			message += ": " + flashSaleErr.Error()
+			if err := server.repriceCart(cart.ID, user); err != nil {
+				log.Printf("Failed to reprice cart %s: %v", cart.ID, err)
+			}
+		}
+		flash.SetFlash(w, r, "error", message)
 		http.Redirect(w, r, "/carts", http.StatusSeeOther)
 		return
 	}
@@ -102,7 +162,7 @@ func (server *Server) Checkout(w http.ResponseWriter, r *http.Request) {
 			orderItems = append(orderItems, models.OrderItemTestimonials{
 				Name:        cartItem.Product.Name,
 				Description: cartItem.Product.ShortDescription,
-				Value:       int(cartItem.Product.Price.IntPart()),
+				Value:       int(cartItem.Pricenew.Int64()),
 				Quantity:    cartItem.Qty,
 				Weight:      int(cartItem.Product.Weight.IntPart()),
 			})
@@ -145,13 +205,13 @@ func (server *Server) Checkout(w http.ResponseWriter, r *http.Request) {
 			OriginContactPhone:      "08115992185",
 			OriginAddress:           "Jl. KH. Harun Nafsi No.106, RT.22, Rapak Dalam, Kec. Loa Janan Ilir, Kota Samarinda, Kalimantan Timur",
 			OriginNote:              "Toko Shafirda",
-			OriginCoordinate:        models.Coordinate{-0.526313085327813, 117.13666900992393},
+			OriginCoordinate:        models.Coordinate{Latitude: shipping.StoreLatitude, Longitude: shipping.StoreLongitude},
 			DestinationContactName:  checkoutRequest.ShippingAddress.FirstName + checkoutRequest.ShippingAddress.LastName,
 			DestinationContactPhone: checkoutRequest.ShippingAddress.Phone,
 			DestinationContactEmail: checkoutRequest.ShippingAddress.Email,
 			DestinationAddress:      checkoutRequest.ShippingAddress.Address1,
 			DestinationNote:         checkoutRequest.ShippingAddress.Address2,
-			DestinationCoordinate:   models.Coordinate{latitude, longitude},
+			DestinationCoordinate:   models.Coordinate{Latitude: latitude, Longitude: longitude},
 			CourierCompany:          "grab",
 			CourierType:             "instant",
 			CourierInsurance:        50000,
@@ -161,7 +221,7 @@ func (server *Server) Checkout(w http.ResponseWriter, r *http.Request) {
 		}
 
 		//memanggil handler create biteship
-		response, err = server.CreateBiteshipOrder(params)
+		response, err = server.Shipping.CreateOrder(params)
 		if err != nil {
 			log.Fatalf("Failed to create order: %v", err)
 		}
@@ -195,7 +255,7 @@ func (server *Server) Checkout(w http.ResponseWriter, r *http.Request) {
 		}
 
 		//memanggil handler create biteship
-		response, err = server.CreateBiteshipOrder(params)
+		response, err = server.Shipping.CreateOrder(params)
 		if err != nil {
 			log.Fatalf("Failed to create order: %v", err)
 		}
@@ -230,19 +290,30 @@ func (server *Server) ShowOrder(w http.ResponseWriter, r *http.Request) {
 
 	orderModel := models.Order{}
 	order, err := orderModel.FindByID(server.DB, vars["id"])
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.NotFound(w, r)
+		return
+	}
 	if err != nil {
 		http.Redirect(w, r, "/products", http.StatusSeeOther)
 		return
 	}
 
+	// Order pelanggan lain dibalas 404 seperti order yang tidak ada.
+	user := auth.CurrentUser(server.DB, w, r)
+	if !policies.CanViewOrder(user, order) {
+		http.NotFound(w, r)
+		return
+	}
+
 	_ = render.HTML(w, http.StatusOK, "show_order", map[string]interface{}{
 		"order":   order,
 		"success": flash.GetFlash(w, r, "success"),
-		"user":    auth.CurrentUser(server.DB, w, r),
+		"user":    user,
 	})
 }
 
-func (server *Server) getSelectedShippingCost(w http.ResponseWriter, r *http.Request) (float64, error) {
+func (server *Server) getSelectedShippingCost(w http.ResponseWriter, r *http.Request) (money.Money, error) {
 	default_location := os.Getenv("API_BITESHIP_SAMARINDA_LOCATION")
 
 	destination := r.FormValue("city_id")
@@ -253,19 +324,19 @@ func (server *Server) getSelectedShippingCost(w http.ResponseWriter, r *http.Req
 	log.Printf("Checkout shipping params: city_id=%s, cour_type=%s, courier=%s, shipping_fee=%s",
 		destination, cour_type, courier, shippingFeeSelected)
 
-	cartID := GetShoppingCartID(w, r)
+	cartID := server.GetShoppingCartID(w, r)
 	cart, _ := GetShoppingCart(server.DB, cartID)
 
 	if cour_type == "pickup" || courier == "pickup" {
 		log.Printf("Pickup selected - no shipping cost")
-		return 0, nil
+		return money.Zero, nil
 	}
 
 	if destination == "" {
 
 		destination = default_location
 		if destination == "" {
-			return 0, errors.New("invalid destination: no city_id provided and no default location configured")
+			return money.Zero, errors.New("invalid destination: no city_id provided and no default location configured")
 		}
 	}
 
@@ -278,24 +349,30 @@ func (server *Server) getSelectedShippingCost(w http.ResponseWriter, r *http.Req
 		longitudeStr := r.FormValue("longitude")
 
 		if latitudeStr == "" || longitudeStr == "" {
-			return 0, errors.New("latitude and longitude required for instant delivery")
+			return money.Zero, errors.New("latitude and longitude required for instant delivery")
 		}
 
-		shippingFeeOptions, err = server.CalculateShippingFeeBiteshipInstant(ShippingFeeParams{
-			Origin:      latitudeStr,
-			Destination: longitudeStr,
-			Weight:      cart.TotalWeight,
-			Couriers:    courier,
+		var destinationCoordinate shipping.Coordinate
+		destinationCoordinate, err = shipping.ParseCoordinate(latitudeStr, longitudeStr)
+		if err != nil {
+			return money.Zero, err
+		}
+
+		shippingFeeOptions, err = server.Shipping.InstantRates(shipping.RateParams{
+			OriginCoordinate:      shipping.StoreCoordinate,
+			DestinationCoordinate: destinationCoordinate,
+			Weight:                cart.TotalWeight,
+			Couriers:              courier,
 		})
 		log.Printf("Instant delivery calculation with lat: %s, lng: %s", latitudeStr, longitudeStr)
 	} else if cour_type == "pickup" {
 
-		return 0, nil
+		return money.Zero, nil
 	} else {
 
 		log.Printf("Regular delivery calculation for: %s", cour_type)
 		destinationAreaID := convertCityIDToBiteshipAreaID(destination)
-		shippingFeeOptions, err = server.CalculateShippingFeeBiteship(ShippingFeeParams{
+		shippingFeeOptions, err = server.Shipping.Rates(shipping.RateParams{
 			Origin:      default_location,
 			Destination: destinationAreaID,
 			Weight:      cart.TotalWeight,
@@ -306,7 +383,7 @@ func (server *Server) getSelectedShippingCost(w http.ResponseWriter, r *http.Req
 
 	if err != nil {
 		log.Printf("Shipping calculation error: %v", err)
-		return 0, errors.New("failed shipping calculation")
+		return money.Zero, errors.New("failed shipping calculation")
 	}
 
 	var selectedShipping models.Pricing
@@ -323,31 +400,35 @@ func (server *Server) getSelectedShippingCost(w http.ResponseWriter, r *http.Req
 
 	if !found {
 		log.Printf("Selected shipping option not found: %s", shippingFeeSelected)
-		return 0, errors.New("selected shipping option not found")
+		return money.Zero, errors.New("selected shipping option not found")
 	}
 
-	log.Printf("Selected shipping cost: %d", selectedShipping.Price)
-	return float64(selectedShipping.Price), nil
+	log.Printf("Selected shipping cost: %s", selectedShipping.Price)
+	return selectedShipping.Price, nil
 }
 
 func (server *Server) SaveOrder(user *models.User, r *CheckoutRequest) (*models.Order, error) {
 	var orderItems []models.OrderItem
 
 	orderID := uuid.New().String()
-	var paymentURL string
 	var err error
 
-	paymentURL, err = server.createPaymentURL(user, r, orderID)
-	if err != nil {
-		return nil, err
-	}
-
 	if len(r.Cart.CartItems) > 0 {
 		for _, cartItem := range r.Cart.CartItems {
+			// Harga pokok disimpan pada item agar laporan margin tidak berubah saat harga pokok produk diperbarui
+			costPrice, err := cartItem.UnitCost(server.DB)
+			if err != nil {
+				return nil, err
+			}
+
 			orderItems = append(orderItems, models.OrderItem{
 				ProductID:       cartItem.ProductID,
 				Qty:             cartItem.Qty,
 				Unit:            cartItem.Unit,
+				ProductUnitID:   cartItem.ProductUnitID,
+				PriceTier:       cartItem.PriceTier,
+				FlashSaleID:     cartItem.FlashSaleID,
+				Pricenew:        cartItem.Pricenew,
 				BasePrice:       cartItem.BasePrice,
 				BaseTotal:       cartItem.BaseTotal,
 				TaxAmount:       cartItem.TaxAmount,
@@ -355,6 +436,7 @@ func (server *Server) SaveOrder(user *models.User, r *CheckoutRequest) (*models.
 				DiscountAmount:  cartItem.DiscountAmount,
 				DiscountPercent: cartItem.DiscountPercent,
 				SubTotal:        cartItem.SubTotal,
+				CostPrice:       costPrice,
 				Sku:             cartItem.Product.Sku,
 				Name:            cartItem.Product.Name,
 				Weight:          cartItem.Product.Weight,
@@ -375,9 +457,6 @@ func (server *Server) SaveOrder(user *models.User, r *CheckoutRequest) (*models.
 		PostCode:   r.ShippingAddress.PostCode,
 	}
 
-	shippingCostDecimal := decimal.NewFromFloat(r.ShippingFee.Fee)
-	grandTotalWithShipping := r.Cart.GrandTotal.Add(shippingCostDecimal)
-
 	orderData := &models.Order{
 		ID:                  orderID,
 		UserID:              user.ID,
@@ -385,59 +464,121 @@ func (server *Server) SaveOrder(user *models.User, r *CheckoutRequest) (*models.
 		OrderCustomer:       orderCustomer,
 		Status:              0,
 		OrderDate:           time.Now(),
-		PaymentDue:          time.Now().AddDate(0, 0, 7),
+		PaymentDue:          time.Now().Add(server.StockHold),
 		PaymentStatus:       consts.OrderPaymentStatusUnpaid,
 		BaseTotalPrice:      r.Cart.BaseTotalPrice,
 		TaxAmount:           r.Cart.TaxAmount,
 		TaxPercent:          r.Cart.TaxPercent,
 		DiscountAmount:      r.Cart.DiscountAmount,
 		DiscountPercent:     r.Cart.DiscountPercent,
-		ShippingCost:        shippingCostDecimal,
-		GrandTotal:          grandTotalWithShipping,
+		ShippingCost:        r.ShippingFee.Fee,
+		GrandTotal:          orderGrandTotal(r.Cart, r.ShippingFee.Fee),
+		VoucherCode:         r.Cart.VoucherCode,
 		ShippingCourier:     r.ShippingFee.Courier,
 		ShippingServiceName: r.ShippingFee.PackageName,
-		PaymentToken:        sql.NullString{String: paymentURL, Valid: true},
 	}
 
-	orderModel := models.Order{}
-	order, err := orderModel.CreateOrder(server.DB, orderData)
+	var order *models.Order
+	err = server.DB.Transaction(func(tx *gorm.DB) error {
+		// Kuota flash sale dicek ulang dengan baris flash sale terkunci, sebelum item order ini ikut terhitung terjual
+		err := models.CheckFlashSalesForUpdate(tx, r.Cart.CartItems)
+		if err != nil {
+			return err
+		}
+
+		orderModel := models.Order{}
+		order, err = orderModel.CreateOrder(tx, orderData)
+		if err != nil {
+			return err
+		}
+
+		// Stok ditahan sampai batas waktu pembayaran agar tidak dibeli pelanggan lain sebelum order dibayar
+		_, err = models.ReserveStock(tx, order.ID, r.Cart.CartItems, order.PaymentDue)
+		if err != nil {
+			return err
+		}
+
+		if order.VoucherCode == "" {
+			return nil
+		}
+
+		// Voucher dikunci lalu batas pemakaiannya dicek ulang agar checkout bersamaan tidak melewati batas tersebut
+		voucherModel := models.Voucher{}
+		voucher, err := voucherModel.FindByCodeForUpdate(tx, order.VoucherCode)
+		if err != nil {
+			return err
+		}
+
+		err = voucher.CheckUsage(tx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&models.VoucherUsage{
+			VoucherID:      voucher.ID,
+			UserID:         user.ID,
+			OrderID:        order.ID,
+			DiscountAmount: order.DiscountAmount,
+		}).Error
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return order, nil
-}
-
-func (server *Server) createPaymentURL(user *models.User, r *CheckoutRequest, orderID string) (string, error) {
-	midtransServerKey := os.Getenv("API_MIDTRANS_SERVER_KEY")
-
-	midtrans.ServerKey = midtransServerKey
+	// Transaksi pembayaran dibuat setelah order dan reservasi stok tersimpan agar checkout yang kalah berebut
+	// stok terakhir tidak meninggalkan transaksi pembayaran tanpa order
+	paymentURL, err := server.createPaymentURL(user, order)
+	if err != nil {
+		server.cancelUnpayableOrder(order.ID)
+		return nil, err
+	}
 
-	var enabledPaymentTypes []snap.SnapPaymentType
+	err = order.SetPaymentToken(server.DB, paymentURL)
+	if err != nil {
+		return nil, err
+	}
 
-	enabledPaymentTypes = append(enabledPaymentTypes, snap.AllSnapPaymentType...)
+	return order, nil
+}
 
-	shippingCostDecimal := decimal.NewFromFloat(r.ShippingFee.Fee)
-	grandTotalWithShipping := r.Cart.GrandTotal.Add(shippingCostDecimal)
+// cancelUnpayableOrder membatalkan order yang transaksi pembayarannya gagal dibuat agar stok yang ditahan
+// dan pemakaian vouchernya langsung dilepas
+func (server *Server) cancelUnpayableOrder(orderID string) {
+	err := server.DB.Transaction(func(tx *gorm.DB) error {
+		orderModel := models.Order{}
+		order, err := orderModel.FindByIDForUpdate(tx, orderID)
+		if err != nil {
+			return err
+		}
 
-	snapRequest := &snap.Request{
-		TransactionDetails: midtrans.TransactionDetails{
-			OrderID:  orderID,
-			GrossAmt: int64(grandTotalWithShipping.IntPart()),
-		},
-		CustomerDetail: &midtrans.CustomerDetails{
-			FName: user.FirstName,
-			LName: user.LastName,
-			Email: user.Email,
-			Phone: user.Phone,
-		},
-		EnabledPayments: enabledPaymentTypes,
+		return order.MarkAsCancelled(tx, consts.OrderPaymentStatusCancelled, "Transaksi pembayaran gagal dibuat")
+	})
+	if err != nil {
+		log.Printf("Failed to cancel order %s without payment: %v", orderID, err)
 	}
+}
 
-	snapResponse, err := snap.CreateTransaction(snapRequest)
+func (server *Server) createPaymentURL(user *models.User, order *models.Order) (string, error) {
+	// Gross amount harus sama persis dengan GrandTotal order agar notifikasi pembayaran cocok. Batas waktu
+	// pembayaran sama dengan reservasi stok agar order tidak dibatalkan selagi VA atau QRIS masih bisa dibayar.
+	transaction, err := server.Payment.CreateTransaction(payment.TransactionRequest{
+		OrderID:     order.ID,
+		GrossAmount: order.GrandTotal.Int64(),
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		Email:       user.Email,
+		Phone:       user.Phone,
+		ExpiresAt:   order.PaymentDue,
+	})
 	if err != nil {
 		return "", err
 	}
 
-	return snapResponse.RedirectURL, nil
+	return transaction.RedirectURL, nil
+}
+
+// orderGrandTotal menjumlahkan total keranjang dengan ongkos kirim lalu membulatkannya ke rupiah penuh,
+// karena payment gateway hanya menerima gross amount dalam rupiah bulat
+func orderGrandTotal(cart *models.Cart, shippingFee money.Money) money.Money {
+	return cart.GrandTotal.Add(shippingFee).Round()
 }
@@ -1,163 +1,168 @@
 package controllers
 
 import (
-	"crypto/sha512"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
-	"os"
 
-	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 
-	"github.com/midtrans/midtrans-go/snap"
-
-	"github.com/gieart87/gotoko/app/consts"
+	"github.com/gieart87/gotoko/app/core/money"
+	"github.com/gieart87/gotoko/app/core/payment"
 	"github.com/gieart87/gotoko/app/models"
 )
 
-func (server *Server) MidtransNotification(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("[DEBUG] MidtransNotification endpoint called")
-	fmt.Printf("[DEBUG] Request method: %s\n", r.Method)
-	fmt.Printf("[DEBUG] Request URL: %s\n", r.URL.String())
-	fmt.Printf("[DEBUG] Request headers: %+v\n", r.Header)
-	fmt.Printf("[DEBUG] Content-Type: %s\n", r.Header.Get("Content-Type"))
-	fmt.Printf("[DEBUG] Content-Length: %s\n", r.Header.Get("Content-Length"))
-
-	var paymentNotification models.MidtransNotification
-
-	err := json.NewDecoder(r.Body).Decode(&paymentNotification)
-	if err != nil {
-		fmt.Printf("[ERROR] Failed to decode JSON payload: %v\n", err)
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		res := Result{Code: http.StatusBadRequest, Message: err.Error()}
-		response, _ := json.Marshal(res)
-		w.Write(response)
-		return
-	}
+// PaymentNotification menerima webhook dari payment gateway (Midtrans atau simulator).
+func (server *Server) PaymentNotification(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
-	fmt.Printf("[DEBUG] Decoded payload: %+v\n", paymentNotification)
-
-	err = validateSignatureKey(&paymentNotification)
+	// Membaca dan memverifikasi payload notifikasi melalui payment gateway
+	paymentNotification, err := server.Payment.ParseNotification(r)
 	if err != nil {
-		fmt.Printf("[ERROR] Signature validation failed: %v\n", err)
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusForbidden)
-		res := Result{Code: http.StatusForbidden, Message: err.Error()}
-		response, _ := json.Marshal(res)
-		w.Write(response)
+		log.Printf("[ERROR] Invalid payment notification: %v", err)
+		if errors.Is(err, payment.ErrInvalidSignature) {
+			writePaymentResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		writePaymentResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	fmt.Println("[DEBUG] Signature validation passed")
 
-	orderModel := models.Order{}
-	order, err := orderModel.FindByID(server.DB, paymentNotification.OrderID)
+	duplicate, _, err := server.processPaymentNotification(paymentNotification)
 	if err != nil {
-		fmt.Printf("[ERROR] Order lookup failed for ID %s: %v\n", paymentNotification.OrderID, err)
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusForbidden)
-		res := Result{Code: http.StatusForbidden, Message: err.Error()}
-		response, _ := json.Marshal(res)
-		w.Write(response)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("[ERROR] Order not found for payment notification %s", paymentNotification.OrderID)
+			writePaymentResponse(w, http.StatusNotFound, "Order not found.")
+			return
+		}
+		// Status selain 2xx membuat Midtrans mengirim ulang notifikasi
+		log.Printf("[ERROR] Failed to process payment notification for order %s: %v", paymentNotification.OrderID, err)
+		writePaymentResponse(w, http.StatusInternalServerError, "Could not process the payment.")
 		return
 	}
-	fmt.Printf("[DEBUG] Order found: ID=%d, Status=%s\n", order.ID, order.Status)
-
-	if order.IsPaid() {
-		fmt.Printf("[DEBUG] Order %d is already paid\n", order.ID)
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusForbidden)
-		res := Result{Code: http.StatusForbidden, Message: "Already paid before."}
-		response, _ := json.Marshal(res)
-		w.Write(response)
+
+	if duplicate {
+		writePaymentResponse(w, http.StatusOK, "Notification already processed.")
 		return
 	}
 
-	paymentModel := models.Payment{}
-	amount, _ := decimal.NewFromString(paymentNotification.GrossAmount)
-	jsonPayload, _ := json.Marshal(paymentNotification)
-	payload := (*json.RawMessage)(&jsonPayload)
-
-	payment, err := paymentModel.CreatePayment(server.DB, &models.Payment{
-		OrderID:           order.ID,
-		Amount:            amount,
-		TransactionID:     paymentNotification.TransactionID,
-		TransactionStatus: paymentNotification.TransactionStatus,
-		Payload:           payload,
-		PaymentType:       paymentNotification.PaymentType,
+	writePaymentResponse(w, http.StatusOK, "Payment saved.")
+}
+
+// processPaymentNotification menyimpan notifikasi sebagai Payment dan menerapkan statusnya ke order
+// dalam satu transaksi database dengan baris order terkunci. Notifikasi dengan transaction ID dan
+// status yang sudah pernah disimpan tidak diproses ulang dan dilaporkan sebagai duplikat. changed menandakan
+// PaymentStatus atau Status order benar-benar berubah.
+func (server *Server) processPaymentNotification(notification *models.MidtransNotification) (duplicate bool, changed bool, err error) {
+
+	err = server.DB.Transaction(func(tx *gorm.DB) error {
+		orderModel := models.Order{}
+		order, err := orderModel.FindByIDForUpdate(tx, notification.OrderID)
+		if err != nil {
+			return err
+		}
+
+		paymentModel := models.Payment{}
+		_, err = paymentModel.FindByTransaction(tx, notification.TransactionID, notification.TransactionStatus)
+		if err == nil {
+			duplicate = true
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		amount, _ := money.Parse(notification.GrossAmount)
+		jsonPayload, _ := json.Marshal(notification)
+		payload := (*json.RawMessage)(&jsonPayload)
+
+		_, err = paymentModel.CreatePayment(tx, &models.Payment{
+			OrderID:           order.ID,
+			Amount:            amount,
+			TransactionID:     notification.TransactionID,
+			TransactionStatus: notification.TransactionStatus,
+			Payload:           payload,
+			PaymentType:       notification.PaymentType,
+		})
+		if err != nil {
+			return err
+		}
+
+		paymentStatus, status := order.PaymentStatus, order.Status
+		err = order.ApplyPaymentNotification(tx, notification)
+		if err != nil {
+			return err
+		}
+		changed = order.PaymentStatus != paymentStatus || order.Status != status
+
+		if changed && order.IsRefundDue() {
+			log.Printf("[WARN] Order %s (%s) dibayar setelah dibatalkan, dana Rp %s harus dikembalikan",
+				order.Code, order.ID, notification.GrossAmount)
+		}
+
+		return nil
 	})
+
+	return duplicate, changed, err
+}
+
+// reconcilePayments mencocokkan order yang masih menunggu pembayaran dengan status transaksi
+// pada payment gateway, lalu menerapkan transisi yang sama seperti webhook. Digunakan untuk
+// memulihkan notifikasi yang terlewat.
+func (server *Server) reconcilePayments() error {
+	orderModel := models.Order{}
+	orders, err := orderModel.GetAwaitingPaymentOrders(server.DB)
 	if err != nil {
-		fmt.Printf("[ERROR] Payment creation failed: %v\n", err)
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		res := Result{Code: http.StatusBadRequest, Message: "Could not process the payment."}
-		response, _ := json.Marshal(res)
-		w.Write(response)
-		return
+		return err
 	}
-	fmt.Printf("[DEBUG] Payment created: ID=%d, Amount=%s, Status=%s\n", payment.ID, amount.String(), paymentNotification.TransactionStatus)
 
-	if isPaymentSuccess(&paymentNotification) {
-		fmt.Printf("[DEBUG] Payment is successful, marking order as paid\n")
-		err = order.MarkAsPaid(server.DB)
+	var updated, unchanged, missing, failed int
+	for _, order := range orders {
+		notification, err := server.Payment.Status(order.ID)
 		if err != nil {
-			fmt.Printf("[ERROR] Failed to mark order as paid: %v\n", err)
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-			res := Result{Code: http.StatusBadRequest, Message: "Could not process the payment."}
-			response, _ := json.Marshal(res)
-			w.Write(response)
-			return
+			if errors.Is(err, payment.ErrTransactionNotFound) {
+				missing++
+				continue
+			}
+			log.Printf("Gagal mengambil status pembayaran order %s: %v", order.ID, err)
+			failed++
+			continue
 		}
-		fmt.Printf("[DEBUG] Order %d successfully marked as paid\n", order.ID)
-	} else {
-		fmt.Printf("[DEBUG] Payment not successful: Status=%s, FraudStatus=%s, PaymentType=%s\n",
-			paymentNotification.TransactionStatus, paymentNotification.FraudStatus, paymentNotification.PaymentType)
-	}
 
-	fmt.Println("[DEBUG] Sending success response")
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	res := Result{Code: http.StatusOK, Message: "Payment saved."}
-	response, _ := json.Marshal(res)
-	w.Write(response)
-}
+		duplicate, changed, err := server.processPaymentNotification(notification)
+		if err != nil {
+			log.Printf("Gagal menerapkan status pembayaran order %s: %v", order.ID, err)
+			failed++
+			continue
+		}
 
-func isPaymentSuccess(payload *models.MidtransNotification) bool {
-	paymentStatus := false
-	if payload.PaymentType == string(snap.PaymentTypeCreditCard) {
-		paymentStatus = (payload.TransactionStatus == consts.PaymentStatusCapture) &&
-			(payload.FraudStatus == consts.FraudStatusAccept)
-	} else {
-		paymentStatus = (payload.TransactionStatus == consts.PaymentStatusSettlement) &&
-			(payload.FraudStatus == consts.FraudStatusAccept)
-	}
-	return paymentStatus
-}
+		if duplicate || !changed {
+			unchanged++
+			continue
+		}
 
-func validateSignatureKey(payload *models.MidtransNotification) error {
-	environment := os.Getenv("APP_ENV")
-	if environment == "development" {
-		return nil
+		fmt.Printf("Order %s (%s): %s\n", order.Code, order.ID, notification.TransactionStatus)
+		updated++
 	}
 
-	signaturePayload := payload.OrderID + payload.StatusCode +
-		payload.GrossAmount + os.Getenv("API_MIDTRANS_SERVER_KEY")
-	sha512Value := sha512.New()
-	sha512Value.Write([]byte(signaturePayload))
+	fmt.Printf("Reconcile selesai: %d order diperiksa, %d diperbarui, %d tidak berubah, %d belum ada transaksi, %d gagal\n",
+		len(orders), updated, unchanged, missing, failed)
 
-	signatureKey := fmt.Sprintf("%x", sha512Value.Sum(nil))
-	if signatureKey != payload.SignatureKey {
-		return errors.New("invalid signature key")
-	}
 	return nil
 }
 
-func (server *Server) PaymentTest(w http.ResponseWriter, r *http.Request) {
-	fmt.Printf("[DEBUG] PaymentTest endpoint called - Method: %s, URL: %s\n", r.Method, r.URL.String())
+// writePaymentResponse menulis respons JSON untuk webhook pembayaran
+func writePaymentResponse(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	res := Result{Code: code, Message: message}
+	response, _ := json.Marshal(res)
+	w.Write(response)
+}
 
+func (server *Server) PaymentTest(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	response := map[string]interface{}{
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/unrolled/render"
+
+	"github.com/gieart87/gotoko/app/core/payment"
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/core/session/flash"
+	"github.com/gieart87/gotoko/app/models"
+	"github.com/gieart87/gotoko/app/policies"
+)
+
+// PaymentSimulator menampilkan halaman pembayaran palsu untuk transaksi simulator.
+func (server *Server) PaymentSimulator(w http.ResponseWriter, r *http.Request) {
+	render := render.New(render.Options{
+		Layout:     "layout",
+		Extensions: []string{".html", ".tmpl"},
+	})
+
+	simulator, ok := server.Payment.(*payment.Simulator)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	vars := mux.Vars(r)
+	user := auth.CurrentUser(server.DB, w, r)
+	if !server.canPayOrder(user, vars["id"]) {
+		http.NotFound(w, r)
+		return
+	}
+
+	transaction, ok := simulator.Transaction(vars["id"])
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	_ = render.HTML(w, http.StatusOK, "payment_simulator", map[string]interface{}{
+		"transaction": transaction,
+		"statuses":    payment.SimulatorStatuses,
+		"error":       flash.GetFlash(w, r, "error"),
+		"user":        user,
+	})
+}
+
+// DoPaymentSimulator menyelesaikan transaksi simulator dengan status yang dipilih
+// lalu mengirim notifikasi ke /payment/notification.
+func (server *Server) DoPaymentSimulator(w http.ResponseWriter, r *http.Request) {
+	simulator, ok := server.Payment.(*payment.Simulator)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	vars := mux.Vars(r)
+	orderID := vars["id"]
+	if !server.canPayOrder(auth.CurrentUser(server.DB, w, r), orderID) {
+		http.NotFound(w, r)
+		return
+	}
+
+	err := simulator.Complete(orderID, r.FormValue("status"))
+	if err != nil {
+		log.Printf("Payment simulator failed for order %s: %v", orderID, err)
+		flash.SetFlash(w, r, "error", "Simulasi pembayaran gagal: "+err.Error())
+		http.Redirect(w, r, "/payment/simulator/"+orderID, http.StatusSeeOther)
+		return
+	}
+
+	flash.SetFlash(w, r, "success", "Simulasi pembayaran berhasil dikirim")
+	http.Redirect(w, r, "/orders/"+orderID, http.StatusSeeOther)
+}
+
+// canPayOrder menandakan user boleh membayar order, order yang tidak ada dianggap tidak boleh
+func (server *Server) canPayOrder(user *models.User, orderID string) bool {
+	orderModel := models.Order{}
+	order, err := orderModel.FindByID(server.DB, orderID)
+	if err != nil {
+		return false
+	}
+
+	return policies.CanViewOrder(user, order)
+}
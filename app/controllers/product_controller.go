@@ -7,6 +7,8 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/gieart87/gotoko/app/core/money"
+	"github.com/gieart87/gotoko/app/core/pricing"
 	"github.com/gieart87/gotoko/app/core/session/auth"
 	"github.com/gieart87/gotoko/app/core/session/flash"
 	"github.com/gieart87/gotoko/app/models"
@@ -14,6 +16,54 @@ import (
 	"github.com/unrolled/render"
 )
 
+// flashSaleBadge adalah harga flash sale satu satuan produk yang ditampilkan di daftar produk
+type flashSaleBadge struct {
+	Tier          pricing.Tier
+	UnitName      string
+	OriginalPrice money.Money // Harga eceran satuan sebelum flash sale, ditampilkan dicoret
+}
+
+// productListing menyiapkan produk untuk template products: mengisi stok yang ditahan order lain, lalu mengembalikan
+// harga flash sale yang sedang berlangsung per ID produk sesuai aturan harga pelanggan. Template membaca peta ini untuk
+// setiap produk yang masih ada stoknya, jadi hasilnya tidak pernah nil.
+func (server *Server) productListing(user *models.User, products []models.Product) (map[string]*flashSaleBadge, error) {
+	productRefs := make([]*models.Product, len(products))
+	for i := range products {
+		productRefs[i] = &products[i]
+	}
+	err := models.LoadHeldStock(server.DB, productRefs...)
+	if err != nil {
+		return nil, err
+	}
+
+	priceList, err := server.Pricing.PriceList(user)
+	if err != nil {
+		return nil, err
+	}
+
+	flashSales := map[string]*flashSaleBadge{}
+	for i := range products {
+		product := &products[i]
+		for j := range product.Units {
+			unit := &product.Units[j]
+			if priceList.FlashSale(product, unit) == nil {
+				continue
+			}
+
+			tier, err := priceList.Quote(product, unit, 1)
+			if err != nil {
+				return nil, err
+			}
+			if tier.FlashSaleID != "" {
+				flashSales[product.ID] = &flashSaleBadge{Tier: tier, UnitName: unit.Name, OriginalPrice: money.New(pricing.ListPrice(unit))}
+				break
+			}
+		}
+	}
+
+	return flashSales, nil
+}
+
 func (server *Server) Products(w http.ResponseWriter, r *http.Request) {
 	// Inisialisasi render dengan pengaturan layout dan ekstensi file
 	render := render.New(render.Options{
@@ -54,6 +104,21 @@ func (server *Server) Products(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Mengambil pohon kategori untuk sidebar
+	categoryModel := models.Category{}
+	categories, err := categoryModel.GetCategoryTree(server.DB)
+	if err != nil {
+		return
+	}
+
+	// Stok yang ditampilkan adalah stok yang belum ditahan order lain, beserta harga flash sale per ID produk
+	user := auth.CurrentUser(server.DB, w, r)
+	flashSales, err := server.productListing(user, *products)
+	if err != nil {
+		http.Error(w, "Gagal mengambil produk", http.StatusInternalServerError)
+		return
+	}
+
 	// Membuat tautan paginasi berdasarkan data yang diperoleh
 	pagination, _ := GetPaginationLinks(server.AppConfig, PaginationParams{
 		Path:        "products",
@@ -65,9 +130,11 @@ func (server *Server) Products(w http.ResponseWriter, r *http.Request) {
 	// Merender halaman produk dengan data yang diperoleh
 	_ = render.HTML(w, http.StatusOK, "products", map[string]interface{}{
 		"products":    products,
+		"flashSales":  flashSales,
 		"pagination":  pagination,
-		"user":        auth.CurrentUser(server.DB, w, r),
+		"user":        user,
 		"searchQuery": searchQuery,
+		"categories":  categories,
 	})
 }
 
@@ -97,12 +164,37 @@ func (server *Server) GetProductBySlug(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = models.LoadHeldStock(server.DB, product)
+	if err != nil {
+		return
+	}
+
+	// Tingkat harga setiap satuan dalam format JSON, dipakai JavaScript untuk menampilkan harga sesuai qty
+	user := auth.CurrentUser(server.DB, w, r)
+	priceList, err := server.Pricing.PriceList(user)
+	if err != nil {
+		http.Error(w, "Gagal menghitung harga", http.StatusInternalServerError)
+		return
+	}
+	unitTiers := map[string]string{}
+	for i := range product.Units {
+		tiers, err := priceList.Tiers(product, &product.Units[i])
+		if err != nil {
+			http.Error(w, "Gagal menghitung harga", http.StatusInternalServerError)
+			return
+		}
+		encoded, _ := json.Marshal(tiers)
+		unitTiers[product.Units[i].ID] = string(encoded)
+	}
+
 	// Merender halaman produk dengan data yang diperoleh
 	_ = render.HTML(w, http.StatusOK, "product", map[string]interface{}{
-		"product": product,
-		"success": flash.GetFlash(w, r, "success"),
-		"error":   flash.GetFlash(w, r, "error"),
-		"user":    auth.CurrentUser(server.DB, w, r),
+		"product":       product,
+		"unitTiers":     unitTiers,
+		"customerGroup": priceList.Group(),
+		"success":       flash.GetFlash(w, r, "success"),
+		"error":         flash.GetFlash(w, r, "error"),
+		"user":          user,
 	})
 }
 
@@ -128,13 +220,11 @@ func (server *Server) SearchProductsAPI(w http.ResponseWriter, r *http.Request)
 
 	// Format response untuk autocomplete
 	type ProductSuggestion struct {
-		Name       string `json:"name"`
-		Slug       string `json:"slug"`
-		Categories string `json:"categories,omitempty"`
-		SATUAN1    string `json:"satuan1,omitempty"`
-		SATUAN2    string `json:"satuan2,omitempty"`
-		SATUAN3    string `json:"satuan3,omitempty"`
-		Price      string `json:"price"`
+		Name       string   `json:"name"`
+		Slug       string   `json:"slug"`
+		Categories string   `json:"categories,omitempty"`
+		Units      []string `json:"units,omitempty"`
+		Price      string   `json:"price"`
 	}
 
 	var suggestions []ProductSuggestion
@@ -143,11 +233,11 @@ func (server *Server) SearchProductsAPI(w http.ResponseWriter, r *http.Request)
 			suggestion := ProductSuggestion{
 				Name:       product.Name,
 				Slug:       product.Slug,
-				Categories: product.Categories,
-				SATUAN1:    product.SATUAN1,
-				SATUAN2:    product.SATUAN2,
-				SATUAN3:    product.SATUAN3,
-				Price:      product.HJ1.String(),
+				Categories: product.CategoryNames(),
+				Price:      product.ListPrice().String(),
+			}
+			for _, unit := range product.Units {
+				suggestion.Units = append(suggestion.Units, unit.Name)
 			}
 			suggestions = append(suggestions, suggestion)
 		}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gieart87/gotoko/app/consts"
+	"github.com/gieart87/gotoko/app/core/payment"
 	"github.com/gieart87/gotoko/app/middlewares"
 	"github.com/gorilla/mux"
 )
@@ -23,25 +24,72 @@ func (server *Server) initializeRoutes() {
 	server.Router.HandleFunc("/products", server.Products).Methods("GET")
 	server.Router.HandleFunc("/api/products/search", server.SearchProductsAPI).Methods("GET")
 	server.Router.HandleFunc("/products/{slug}", server.GetProductBySlug).Methods("GET")
+	server.Router.HandleFunc("/categories/{slug}", server.GetCategoryBySlug).Methods("GET")
 
 	server.Router.HandleFunc("/checkAWB", server.checkAWB).Methods("GET")
 	server.Router.HandleFunc("/cek-resi", server.CekResiHandler).Methods("POST")
 
-	server.Router.HandleFunc("/carts", middlewares.AuthMiddleware(server.GetCart)).Methods("GET")
-	server.Router.HandleFunc("/carts", middlewares.AuthMiddleware(server.AddItemToCart)).Methods("POST")
-	server.Router.HandleFunc("/carts/update", middlewares.AuthMiddleware(server.UpdateCart)).Methods("POST")
+	// Tamu boleh mengisi keranjang, isinya digabung ke keranjang pelanggan saat login atau register
+	server.Router.HandleFunc("/carts", server.GetCart).Methods("GET")
+	server.Router.HandleFunc("/carts", server.AddItemToCart).Methods("POST")
+	server.Router.HandleFunc("/carts/update", server.UpdateCart).Methods("POST")
+	server.Router.HandleFunc("/carts/voucher", middlewares.AuthMiddleware(server.RedeemVoucher)).Methods("POST")
+	server.Router.HandleFunc("/carts/voucher/remove", middlewares.AuthMiddleware(server.RemoveVoucher)).Methods("POST")
 
 	server.Router.HandleFunc("/carts/calculate-shipping", middlewares.AuthMiddleware(server.CalculateShippingBiteship)).Methods("POST")
 	server.Router.HandleFunc("/carts/apply-shipping", middlewares.AuthMiddleware(server.ApplyShipping)).Methods("POST")
-	server.Router.HandleFunc("/carts/remove/{id}", middlewares.AuthMiddleware(server.RemoveItemByID)).Methods("GET")
+	server.Router.HandleFunc("/carts/remove/{id}", server.RemoveItemByID).Methods("GET")
+
+	// API keranjang untuk memperbarui keranjang tanpa memuat ulang halaman, memakai sesi yang sama dengan /carts
+	server.Router.HandleFunc("/api/v1/cart", server.GetCartAPI).Methods("GET")
+	server.Router.HandleFunc("/api/v1/cart", server.ClearCartAPI).Methods("DELETE")
+	server.Router.HandleFunc("/api/v1/cart/items", server.AddCartItemAPI).Methods("POST")
+	server.Router.HandleFunc("/api/v1/cart/items/{id}", server.UpdateCartItemAPI).Methods("PATCH")
+	server.Router.HandleFunc("/api/v1/cart/items/{id}", server.RemoveCartItemAPI).Methods("DELETE")
 
 	server.Router.HandleFunc("/orders/checkout", middlewares.AuthMiddleware(server.Checkout)).Methods("POST")
 	server.Router.HandleFunc("/orders/{id}", middlewares.AuthMiddleware(server.ShowOrder)).Methods("GET")
 
-	server.Router.HandleFunc("/payment/notification", middlewares.CORSMiddleware(server.MidtransNotification)).Methods("POST", "OPTIONS")
+	server.Router.HandleFunc("/payment/notification", middlewares.CORSMiddleware(server.PaymentNotification)).Methods("POST", "OPTIONS")
 	server.Router.HandleFunc("/payment/test", middlewares.CORSMiddleware(server.PaymentTest)).Methods("GET", "POST")
+	if _, ok := server.Payment.(*payment.Simulator); ok {
+		server.Router.HandleFunc("/payment/simulator/{id}", middlewares.AuthMiddleware(server.PaymentSimulator)).Methods("GET")
+		server.Router.HandleFunc("/payment/simulator/{id}", middlewares.AuthMiddleware(server.DoPaymentSimulator)).Methods("POST")
+	}
 	server.Router.HandleFunc("/admin/dashboard", middlewares.AuthMiddleware(middlewares.RoleMiddleware(server.AdminDashboard, server.DB, consts.RoleAdmin))).Methods("GET")
 
+	admin := func(next http.HandlerFunc) http.HandlerFunc {
+		return middlewares.AuthMiddleware(middlewares.RoleMiddleware(next, server.DB, consts.RoleAdmin))
+	}
+	server.Router.HandleFunc("/admin/price-rules", admin(server.AdminPriceRules)).Methods("GET")
+	server.Router.HandleFunc("/admin/price-rules", admin(server.AdminCreatePriceRule)).Methods("POST")
+	server.Router.HandleFunc("/admin/price-rules/new", admin(server.AdminNewPriceRule)).Methods("GET")
+	server.Router.HandleFunc("/admin/price-rules/{id}/edit", admin(server.AdminEditPriceRule)).Methods("GET")
+	server.Router.HandleFunc("/admin/price-rules/{id}", admin(server.AdminUpdatePriceRule)).Methods("POST")
+	server.Router.HandleFunc("/admin/price-rules/{id}/delete", admin(server.AdminDeletePriceRule)).Methods("POST")
+	server.Router.HandleFunc("/admin/vouchers", admin(server.AdminVouchers)).Methods("GET")
+	server.Router.HandleFunc("/admin/vouchers", admin(server.AdminCreateVoucher)).Methods("POST")
+	server.Router.HandleFunc("/admin/vouchers/new", admin(server.AdminNewVoucher)).Methods("GET")
+	server.Router.HandleFunc("/admin/vouchers/{id}/edit", admin(server.AdminEditVoucher)).Methods("GET")
+	server.Router.HandleFunc("/admin/vouchers/{id}", admin(server.AdminUpdateVoucher)).Methods("POST")
+	server.Router.HandleFunc("/admin/vouchers/{id}/delete", admin(server.AdminDeleteVoucher)).Methods("POST")
+	server.Router.HandleFunc("/admin/flash-sales", admin(server.AdminFlashSales)).Methods("GET")
+	server.Router.HandleFunc("/admin/flash-sales", admin(server.AdminCreateFlashSale)).Methods("POST")
+	server.Router.HandleFunc("/admin/flash-sales/new", admin(server.AdminNewFlashSale)).Methods("GET")
+	server.Router.HandleFunc("/admin/flash-sales/{id}/edit", admin(server.AdminEditFlashSale)).Methods("GET")
+	server.Router.HandleFunc("/admin/flash-sales/{id}", admin(server.AdminUpdateFlashSale)).Methods("POST")
+	server.Router.HandleFunc("/admin/flash-sales/{id}/delete", admin(server.AdminDeleteFlashSale)).Methods("POST")
+	server.Router.HandleFunc("/admin/reports/margin", admin(server.AdminMarginReport)).Methods("GET")
+	server.Router.HandleFunc("/admin/reports/margin/export", admin(server.AdminExportMarginReport)).Methods("GET")
+	server.Router.HandleFunc("/admin/customer-groups", admin(server.AdminCustomerGroups)).Methods("GET")
+	server.Router.HandleFunc("/admin/customer-groups", admin(server.AdminCreateCustomerGroup)).Methods("POST")
+	server.Router.HandleFunc("/admin/customer-groups/new", admin(server.AdminNewCustomerGroup)).Methods("GET")
+	server.Router.HandleFunc("/admin/customer-groups/{id}/edit", admin(server.AdminEditCustomerGroup)).Methods("GET")
+	server.Router.HandleFunc("/admin/customer-groups/{id}", admin(server.AdminUpdateCustomerGroup)).Methods("POST")
+	server.Router.HandleFunc("/admin/customer-groups/{id}/delete", admin(server.AdminDeleteCustomerGroup)).Methods("POST")
+	server.Router.HandleFunc("/admin/customers", admin(server.AdminCustomers)).Methods("GET")
+	server.Router.HandleFunc("/admin/customers/{id}/customer-group", admin(server.AdminUpdateCustomerGroupOfUser)).Methods("POST")
+
 	staticFileDirectory := http.Dir("./assets/")
 	staticFileHandler := http.StripPrefix("/public/", http.FileServer(staticFileDirectory))
 	server.Router.PathPrefix("/public/").Handler(staticFileHandler).Methods("GET")
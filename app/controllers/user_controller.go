@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"log"
 	"net/http"
 
 	"github.com/gieart87/gotoko/app/core/session/auth"
@@ -44,6 +45,11 @@ func (server *Server) DoLogin(w http.ResponseWriter, r *http.Request) {
 	session.Values["id"] = user.ID
 	session.Save(r, w)
 
+	// Isi keranjang sebelum login digabung ke keranjang pelanggan
+	if _, err := server.attachShoppingCart(w, r, user); err != nil {
+		log.Printf("Failed to attach cart to user %s: %v", user.ID, err)
+	}
+
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
@@ -102,6 +108,11 @@ func (server *Server) DoRegister(w http.ResponseWriter, r *http.Request) {
 	session.Values["id"] = user.ID
 	session.Save(r, w)
 
+	// Isi keranjang sebelum login digabung ke keranjang pelanggan
+	if _, err := server.attachShoppingCart(w, r, user); err != nil {
+		log.Printf("Failed to attach cart to user %s: %v", user.ID, err)
+	}
+
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
@@ -109,6 +120,7 @@ func (server *Server) Logout(w http.ResponseWriter, r *http.Request) {
 	session, _ := auth.GetSessionUser(r)
 	session.Values["id"] = nil
 	session.Save(r, w)
+	forgetShoppingCart(w, r)
 
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
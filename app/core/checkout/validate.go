@@ -0,0 +1,249 @@
+package checkout
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/gieart87/gotoko/app/core/money"
+	"github.com/gieart87/gotoko/app/core/pricing"
+	"github.com/gieart87/gotoko/app/models"
+)
+
+// Jenis perubahan item keranjang saat checkout
+const (
+	ChangePrice   = "price"   // Harga satuan berubah, misalnya setelah import harga mingguan
+	ChangeQty     = "qty"     // Qty dikurangi karena stok tidak mencukupi
+	ChangeRemoved = "removed" // Item dihapus karena produk atau satuannya tidak dijual lagi atau stoknya habis
+)
+
+// Change adalah perubahan item keranjang yang ditemukan saat checkout. Keranjang sudah diperbarui,
+// pelanggan perlu melihat perubahan ini sebelum checkout diulang.
+type Change struct {
+	Type      string      `json:"type"`
+	ItemID    string      `json:"item_id"`
+	ProductID string      `json:"product_id"`
+	Name      string      `json:"name"`
+	Unit      string      `json:"unit"`
+	OldPrice  money.Money `json:"old_price"`
+	NewPrice  money.Money `json:"new_price"`
+	OldQty    int         `json:"old_qty"`
+	NewQty    int         `json:"new_qty"`
+	Reason    string      `json:"reason,omitempty"`
+}
+
+// Message menjelaskan perubahan untuk ditampilkan ke pelanggan
+func (c Change) Message() string {
+	switch c.Type {
+	case ChangePrice:
+		return fmt.Sprintf("Harga %s per %s berubah dari %s menjadi %s", c.Name, c.Unit, c.OldPrice.String(), c.NewPrice.String())
+	case ChangeQty:
+		return fmt.Sprintf("Jumlah %s dikurangi dari %d menjadi %d %s karena stok tidak mencukupi", c.Name, c.OldQty, c.NewQty, c.Unit)
+	default:
+		return fmt.Sprintf("%s (%s) dihapus dari keranjang: %s", c.Name, c.Unit, c.Reason)
+	}
+}
+
+// line adalah item keranjang yang produk dan satuannya masih dijual
+type line struct {
+	item    *models.CartItem
+	product *models.Product
+	unit    *models.ProductUnit
+}
+
+// Validate memeriksa ulang setiap item keranjang sebelum order dibuat: item dengan produk yang sudah dihapus atau
+// nonaktif dan satuan yang sudah dihapus dibuang, qty dikurangi sampai sesuai stok tersedia dalam satuan dasar,
+// lalu harganya dihitung ulang dengan harga dan aturan harga saat ini. Keranjang langsung diperbarui dan
+// perubahannya dikembalikan, kosong berarti checkout boleh dilanjutkan.
+func Validate(db *gorm.DB, engine *pricing.Engine, cart *models.Cart, user *models.User) ([]Change, error) {
+	priceList, err := engine.PriceList(user)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	err = db.Transaction(func(tx *gorm.DB) error {
+		lines, removed, err := availableLines(tx, cart)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, removed...)
+
+		lines, adjusted, err := fitStock(tx, cart, lines)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, adjusted...)
+
+		repriced, err := reprice(tx, cart, priceList, lines)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, repriced...)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// availableLines membuang item yang produk atau satuannya tidak dijual lagi
+func availableLines(tx *gorm.DB, cart *models.Cart) ([]line, []Change, error) {
+	var lines []line
+	var changes []Change
+
+	productModel := models.Product{}
+	for i := range cart.CartItems {
+		item := &cart.CartItems[i]
+
+		reason := ""
+		product, err := productModel.FindByID(tx, item.ProductID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			reason = "produk sudah tidak dijual"
+		} else if err != nil {
+			return nil, nil, err
+		} else if !product.IsActive() {
+			reason = "produk sedang tidak dijual"
+		}
+
+		var unit *models.ProductUnit
+		if reason == "" {
+			var ok bool
+			unit, ok = product.FindUnitForItem(item.ProductUnitID, item.Unit)
+			if !ok {
+				reason = "satuan sudah tidak dijual"
+			}
+		}
+
+		if reason != "" {
+			err = cart.RemoveItemByID(tx, item.ID)
+			if err != nil {
+				return nil, nil, err
+			}
+			changes = append(changes, newChange(ChangeRemoved, item, reason))
+			continue
+		}
+
+		lines = append(lines, line{item: item, product: product, unit: unit})
+	}
+
+	return lines, changes, nil
+}
+
+// fitStock mengurangi qty item sampai sesuai stok yang belum ditahan order lain. Item produk yang sama
+// dari satuan berbeda dipenuhi berurutan sesuai urutan di keranjang, item yang tidak kebagian stok dibuang.
+func fitStock(tx *gorm.DB, cart *models.Cart, lines []line) ([]line, []Change, error) {
+	var productIDs []string
+	remaining := map[string]int{}
+	for _, l := range lines {
+		if _, ok := remaining[l.product.ID]; !ok {
+			productIDs = append(productIDs, l.product.ID)
+			remaining[l.product.ID] = l.product.Stock
+		}
+	}
+	if len(productIDs) == 0 {
+		return lines, nil, nil
+	}
+
+	held, err := models.HeldStock(tx, productIDs, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	for productID, qty := range held {
+		remaining[productID] -= qty
+	}
+
+	var fitted []line
+	var changes []Change
+	for _, l := range lines {
+		baseQty := l.unit.ToBaseQty(l.item.Qty)
+		if baseQty <= remaining[l.product.ID] {
+			remaining[l.product.ID] -= baseQty
+			fitted = append(fitted, l)
+			continue
+		}
+
+		qty := l.unit.AvailableQty(remaining[l.product.ID])
+		if qty == 0 {
+			err = cart.RemoveItemByID(tx, l.item.ID)
+			if err != nil {
+				return nil, nil, err
+			}
+			changes = append(changes, newChange(ChangeRemoved, l.item, "stok habis"))
+			continue
+		}
+
+		_, err = cart.UpdateItemQty(tx, l.item.ID, qty)
+		if err != nil {
+			return nil, nil, err
+		}
+		change := newChange(ChangeQty, l.item, "")
+		change.NewQty = qty
+		changes = append(changes, change)
+
+		l.item.Qty = qty
+		remaining[l.product.ID] -= l.unit.ToBaseQty(qty)
+		fitted = append(fitted, l)
+	}
+
+	return fitted, changes, nil
+}
+
+// reprice menghitung ulang harga item dengan qty akhirnya. Perubahan label tingkat harga tanpa
+// perubahan harga disimpan tanpa dilaporkan.
+func reprice(tx *gorm.DB, cart *models.Cart, priceList *pricing.PriceList, lines []line) ([]Change, error) {
+	var changes []Change
+	for _, l := range lines {
+		tier, err := priceList.Quote(l.product, l.unit, l.item.Qty)
+		if err != nil {
+			return nil, err
+		}
+
+		if tier.Price.Equal(l.item.Pricenew) && tier.Label == l.item.PriceTier && tier.FlashSaleID == l.item.FlashSaleID {
+			continue
+		}
+
+		_, err = cart.UpdateItemPrice(tx, l.item.ID, tier.Price, tier.Label, tier.FlashSaleID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !tier.Price.Equal(l.item.Pricenew) {
+			change := newChange(ChangePrice, l.item, tier.Label)
+			change.NewPrice = tier.Price
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+// newChange membuat perubahan item dengan harga dan qty sebelum perubahan sebagai nilai awal. Produk yang sudah
+// dihapus tidak ikut dimuat bersama item, namanya diganti ID produk.
+func newChange(changeType string, item *models.CartItem, reason string) Change {
+	change := Change{
+		Type:      changeType,
+		ItemID:    item.ID,
+		ProductID: item.ProductID,
+		Name:      item.Product.Name,
+		Unit:      item.Unit,
+		OldPrice:  item.Pricenew,
+		NewPrice:  item.Pricenew,
+		OldQty:    item.Qty,
+		NewQty:    item.Qty,
+		Reason:    reason,
+	}
+	if change.Name == "" {
+		change.Name = item.ProductID
+	}
+	if changeType == ChangeRemoved {
+		change.NewQty = 0
+	}
+
+	return change
+}
@@ -0,0 +1,349 @@
+package importer
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gosimple/slug"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"gorm.io/gorm"
+
+	"github.com/gieart87/gotoko/app/models"
+)
+
+// ImageVariant adalah ukuran gambar yang dibuat dari file asli
+type ImageVariant struct {
+	Name     string // Nama direktori varian, misalnya "medium"
+	MaxWidth int    // Lebar maksimum dalam piksel, gambar yang lebih kecil tidak diperbesar
+}
+
+// ImageVariants adalah ukuran yang disimpan ke kolom ExtraLarge, Large, Medium dan Small
+var ImageVariants = []ImageVariant{
+	{Name: "xl", MaxWidth: 1200},
+	{Name: "large", MaxWidth: 800},
+	{Name: "medium", MaxWidth: 400},
+	{Name: "small", MaxWidth: 150},
+}
+
+// ProductImageDir adalah direktori gambar produk relatif terhadap direktori assets
+const ProductImageDir = "uploads/products"
+
+var imageExtensions = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true}
+
+// imageSuffix mengenali nama file dengan nomor urut, misalnya ABC123_2.jpg atau ABC123-2.jpg
+var imageSuffix = regexp.MustCompile(`^(.+?)[_-]\d+$`)
+
+// ImageImporter mengimpor gambar produk dari sebuah direktori. Nama file (tanpa ekstensi)
+// harus sama dengan ID atau SKU produk.
+type ImageImporter struct {
+	DB        *gorm.DB
+	AssetsDir string // Direktori yang dilayani di /public/, biasanya "assets"
+	Replace   bool   // Jika true, gambar lama produk dihapus setelah gambar baru berhasil disimpan
+	DryRun    bool   // Jika true, tidak ada file maupun data yang ditulis
+}
+
+// NewImageImporter membuat ImageImporter baru
+func NewImageImporter(db *gorm.DB, assetsDir string, replace bool, dryRun bool) *ImageImporter {
+	return &ImageImporter{DB: db, AssetsDir: assetsDir, Replace: replace, DryRun: dryRun}
+}
+
+// ImageResult adalah hasil import satu file gambar
+type ImageResult struct {
+	File      string
+	ProductID string
+	Action    string
+	Path      string
+	Error     string
+}
+
+// ImageReport merangkum hasil import gambar
+type ImageReport struct {
+	Results   []ImageResult
+	Created   int
+	Unchanged int
+	Failed    int
+}
+
+func (r *ImageReport) add(result ImageResult) {
+	switch result.Action {
+	case ActionCreated:
+		r.Created++
+	case ActionUnchanged:
+		r.Unchanged++
+	case ActionFailed:
+		r.Failed++
+	}
+
+	r.Results = append(r.Results, result)
+}
+
+// WriteSummary menuliskan hasil setiap file dan ringkasannya
+func (r *ImageReport) WriteSummary(w io.Writer) {
+	for _, result := range r.Results {
+		switch result.Action {
+		case ActionCreated:
+			fmt.Fprintf(w, "CREATE %s -> %s (%s)\n", result.File, result.ProductID, result.Path)
+		case ActionFailed:
+			fmt.Fprintf(w, "FAILED %s: %s\n", result.File, result.Error)
+		}
+	}
+
+	fmt.Fprintf(w, "Created   : %d\n", r.Created)
+	fmt.Fprintf(w, "Unchanged : %d\n", r.Unchanged)
+	fmt.Fprintf(w, "Failed    : %d\n", r.Failed)
+}
+
+// Import memproses semua file gambar di sourceDir
+func (i *ImageImporter) Import(sourceDir string) (*ImageReport, error) {
+	entries, err := os.ReadDir(sourceDir)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca direktori gambar: %w", err)
+	}
+
+	report := &ImageReport{}
+	cleaned := map[string]bool{}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !imageExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+			continue
+		}
+
+		result := ImageResult{File: entry.Name()}
+		product, err := i.findProduct(entry.Name())
+		if err != nil {
+			report.add(result.fail(err))
+			continue
+		}
+		result.ProductID = product.ID
+
+		result, productImage := i.importFile(filepath.Join(sourceDir, entry.Name()), product, result)
+
+		// Gambar lama dibersihkan sekali per produk, setelah gambar baru pertama berhasil disimpan, agar file yang
+		// rusak tidak membuat produk kehilangan gambarnya dan beberapa file untuk produk yang sama tetap tersimpan.
+		// Tanpa --replace, hanya baris kosong peninggalan import lama yang dihapus.
+		if productImage != nil && !cleaned[product.ID] {
+			if err := i.removeOldImages(productImage); err != nil {
+				result = result.fail(fmt.Errorf("gambar baru tersimpan tetapi gambar lama gagal dihapus: %w", err))
+			}
+			cleaned[product.ID] = true
+		}
+
+		report.add(result)
+	}
+
+	return report, nil
+}
+
+// findProduct mencari produk berdasarkan nama file, dicocokkan ke ID lalu SKU
+func (i *ImageImporter) findProduct(fileName string) (*models.Product, error) {
+	name := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	candidates := []string{name}
+	if match := imageSuffix.FindStringSubmatch(name); match != nil {
+		candidates = append(candidates, match[1])
+	}
+
+	for _, candidate := range candidates {
+		var product models.Product
+		err := i.DB.Where("id = ? OR sku = ?", candidate, candidate).First(&product).Error
+		if err == nil {
+			return &product, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("produk dengan ID atau SKU %q tidak ditemukan", name)
+}
+
+// importFile menyimpan file asli beserta variannya lalu membuat baris ProductImage. Baris yang dibuat
+// dikembalikan, nil jika gagal, tidak berubah atau DryRun.
+func (i *ImageImporter) importFile(sourcePath string, product *models.Product, result ImageResult) (ImageResult, *models.ProductImage) {
+	content, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return result.fail(err), nil
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return result.fail(fmt.Errorf("file bukan gambar yang didukung: %w", err)), nil
+	}
+
+	// Nama file memakai hash isi gambar sehingga import ulang file yang sama tidak menambah baris baru
+	ext := strings.ToLower(filepath.Ext(sourcePath))
+	hash := fmt.Sprintf("%x", sha1.Sum(content))[:8]
+	fileName := fmt.Sprintf("%s-%s%s", slug.Make(product.ID), hash, ext)
+
+	productImage := models.ProductImage{
+		ID:        uuid.New().String(),
+		ProductID: product.ID,
+		Path:      filepath.ToSlash(filepath.Join(ProductImageDir, "original", fileName)),
+	}
+	result.Path = productImage.Path
+
+	var count int64
+	err = i.DB.Model(&models.ProductImage{}).Where("product_id = ? AND path = ?", product.ID, productImage.Path).Count(&count).Error
+	if err != nil {
+		return result.fail(err), nil
+	}
+	if count > 0 && !i.Replace {
+		result.Action = ActionUnchanged
+		return result, nil
+	}
+
+	result.Action = ActionCreated
+	if i.DryRun {
+		return result, nil
+	}
+
+	if err := i.writeFile(productImage.Path, content); err != nil {
+		return result.fail(err), nil
+	}
+
+	variantFormat := format
+	if variantFormat != "png" && variantFormat != "gif" {
+		variantFormat = "jpeg"
+		fileName = strings.TrimSuffix(fileName, ext) + ".jpg"
+	}
+
+	paths := map[string]string{}
+	for _, variant := range ImageVariants {
+		path := filepath.ToSlash(filepath.Join(ProductImageDir, variant.Name, fileName))
+		if err := i.writeVariant(path, resize(img, variant.MaxWidth), variantFormat); err != nil {
+			return result.fail(err), nil
+		}
+		paths[variant.Name] = path
+	}
+
+	productImage.ExtraLarge = paths["xl"]
+	productImage.Large = paths["large"]
+	productImage.Medium = paths["medium"]
+	productImage.Small = paths["small"]
+
+	if err := i.DB.Create(&productImage).Error; err != nil {
+		return result.fail(err), nil
+	}
+
+	return result, &productImage
+}
+
+// removeOldImages menghapus gambar produk selain kept. Dengan Replace, baris lama beserta file asli dan variannya
+// dihapus, kecuali file yang masih dipakai baris lain. Tanpa Replace, hanya baris tanpa path yang dihapus.
+func (i *ImageImporter) removeOldImages(kept *models.ProductImage) error {
+	query := i.DB.Where("product_id = ? AND id <> ?", kept.ProductID, kept.ID)
+	if !i.Replace {
+		query = query.Where("path = '' OR path IS NULL")
+	}
+
+	var oldImages []models.ProductImage
+	if err := query.Find(&oldImages).Error; err != nil {
+		return err
+	}
+	if len(oldImages) == 0 {
+		return nil
+	}
+
+	if err := i.DB.Delete(&oldImages).Error; err != nil {
+		return err
+	}
+
+	for _, oldImage := range oldImages {
+		for _, path := range []string{oldImage.Path, oldImage.ExtraLarge, oldImage.Large, oldImage.Medium, oldImage.Small} {
+			if err := i.removeUnusedFile(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeUnusedFile menghapus file gambar di AssetsDir jika tidak ada lagi baris ProductImage yang memakainya
+func (i *ImageImporter) removeUnusedFile(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	var count int64
+	err := i.DB.Model(&models.ProductImage{}).
+		Where("path = ? OR extra_large = ? OR large = ? OR medium = ? OR small = ?", path, path, path, path, path).
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return err
+	}
+
+	err = os.Remove(filepath.Join(i.AssetsDir, path))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (i *ImageImporter) writeFile(path string, content []byte) error {
+	fullPath := filepath.Join(i.AssetsDir, path)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(fullPath, content, 0644)
+}
+
+func (i *ImageImporter) writeVariant(path string, img image.Image, format string) error {
+	fullPath := filepath.Join(i.AssetsDir, path)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(fullPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	switch format {
+	case "png":
+		return png.Encode(f, img)
+	case "gif":
+		return gif.Encode(f, img, nil)
+	default:
+		return jpeg.Encode(f, img, &jpeg.Options{Quality: 85})
+	}
+}
+
+// resize memperkecil gambar ke lebar maksimum dengan tetap menjaga rasio
+func resize(img image.Image, maxWidth int) image.Image {
+	bounds := img.Bounds()
+	if bounds.Dx() <= maxWidth {
+		return img
+	}
+
+	height := bounds.Dy() * maxWidth / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, maxWidth, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+
+	return dst
+}
+
+func (r ImageResult) fail(err error) ImageResult {
+	r.Action = ActionFailed
+	r.Error = err.Error()
+	return r
+}
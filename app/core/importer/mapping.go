@@ -0,0 +1,103 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ColumnMapping memetakan satu field produk ke nama header di spreadsheet
+type ColumnMapping struct {
+	Field    string   `json:"field"`    // Nama field pada models.Product, misalnya Name atau Unit1.RetailPrice
+	Headers  []string `json:"headers"`  // Nama header yang diterima, tidak membedakan huruf besar/kecil
+	Required bool     `json:"required"` // Sel tidak boleh kosong
+	Optional bool     `json:"optional"` // Header boleh tidak ada, field tersebut tidak akan diubah
+}
+
+// Mapping adalah konfigurasi kolom spreadsheet yang dibaca dari file JSON
+type Mapping struct {
+	Sheet   string          `json:"sheet"` // Nama sheet, kosong berarti sheet pertama
+	Columns []ColumnMapping `json:"columns"`
+}
+
+// LoadMapping membaca file mapping JSON dan memvalidasi nama field di dalamnya
+func LoadMapping(path string) (*Mapping, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca file mapping: %w", err)
+	}
+
+	var mapping Mapping
+	err = json.Unmarshal(content, &mapping)
+	if err != nil {
+		return nil, fmt.Errorf("format file mapping %s tidak valid: %w", path, err)
+	}
+
+	hasID := false
+	for _, column := range mapping.Columns {
+		if _, ok := findProductField(column.Field); !ok {
+			return nil, fmt.Errorf("field %q pada file mapping tidak dikenal", column.Field)
+		}
+		if len(column.Headers) == 0 {
+			return nil, fmt.Errorf("field %q pada file mapping tidak memiliki header", column.Field)
+		}
+		if column.Field == "ID" {
+			hasID = true
+		}
+	}
+
+	if !hasID {
+		return nil, fmt.Errorf("file mapping harus memetakan field ID")
+	}
+
+	return &mapping, nil
+}
+
+// resolvedColumn adalah kolom mapping yang sudah ditemukan posisinya di header
+type resolvedColumn struct {
+	ColumnMapping
+	Header string // Header sebagaimana tertulis di spreadsheet
+	Index  int    // Posisi kolom, dimulai dari 0
+	field  productField
+}
+
+// resolve mencari posisi setiap kolom mapping berdasarkan baris header
+func (m *Mapping) resolve(header []string) ([]resolvedColumn, error) {
+	positions := map[string]int{}
+	for index, name := range header {
+		key := normalizeHeader(name)
+		if _, exists := positions[key]; !exists && key != "" {
+			positions[key] = index
+		}
+	}
+
+	var columns []resolvedColumn
+	var missing []string
+	for _, column := range m.Columns {
+		field, _ := findProductField(column.Field)
+
+		found := false
+		for _, name := range column.Headers {
+			if index, ok := positions[normalizeHeader(name)]; ok {
+				columns = append(columns, resolvedColumn{ColumnMapping: column, Header: strings.TrimSpace(header[index]), Index: index, field: field})
+				found = true
+				break
+			}
+		}
+
+		if !found && !column.Optional {
+			missing = append(missing, strings.Join(column.Headers, "/"))
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("header tidak ditemukan di spreadsheet: %s", strings.Join(missing, ", "))
+	}
+
+	return columns, nil
+}
+
+func normalizeHeader(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,494 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+	"github.com/gosimple/slug"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/gieart87/gotoko/app/models"
+)
+
+// Tipe nilai sel angka yang divalidasi importer
+const (
+	TypeInt     = "bilangan bulat"
+	TypeDecimal = "desimal"
+)
+
+// productField adalah kolom produk yang dapat di-import dari spreadsheet
+type productField struct {
+	Name string                              // Nama field pada struct models.Product, atau UnitN.<Atribut> untuk satuan ke-N
+	ptr  func(p *models.Product) interface{} // Pointer ke field, dipakai untuk membaca dan mengisi nilai
+
+	unit    int                                     // Urutan satuan (mulai dari 1) untuk field satuan
+	unitPtr func(u *models.ProductUnit) interface{} // Pointer ke field satuan
+}
+
+// productFields adalah daftar field yang dapat diambil dari spreadsheet POS
+var productFields = []productField{
+	{Name: "ID", ptr: func(p *models.Product) interface{} { return &p.ID }},
+	{Name: "Name", ptr: func(p *models.Product) interface{} { return &p.Name }},
+	{Name: "Categories", ptr: func(p *models.Product) interface{} { return &p.Categories }},
+	{Name: "Stock", ptr: func(p *models.Product) interface{} { return &p.Stock }},
+	{Name: "Supplier", ptr: func(p *models.Product) interface{} { return &p.Supplier }},
+	{Name: "TaxClassID", ptr: func(p *models.Product) interface{} { return &p.TaxClassID }}, // Berisi kode kelas pajak, misalnya bebas-ppn
+}
+
+// unitFields adalah atribut satuan yang dapat dipetakan sebagai UnitN.<Atribut>, misalnya Unit2.WholesalePrice
+var unitFields = map[string]func(u *models.ProductUnit) interface{}{
+	"Name":           func(u *models.ProductUnit) interface{} { return &u.Name },
+	"Conversion":     func(u *models.ProductUnit) interface{} { return &u.Conversion },
+	"CostPrice":      func(u *models.ProductUnit) interface{} { return &u.CostPrice },
+	"RetailPrice":    func(u *models.ProductUnit) interface{} { return &u.RetailPrice },
+	"WholesalePrice": func(u *models.ProductUnit) interface{} { return &u.WholesalePrice },
+	"Barcode":        func(u *models.ProductUnit) interface{} { return &u.Barcode },
+}
+
+func findProductField(name string) (productField, bool) {
+	for _, field := range productFields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+
+	// Field satuan ditulis UnitN.<Atribut>, N dimulai dari 1
+	var unit int
+	var attribute string
+	if n, _ := fmt.Sscanf(strings.Replace(name, ".", " ", 1), "Unit%d %s", &unit, &attribute); n == 2 && unit > 0 {
+		if unitPtr, ok := unitFields[attribute]; ok {
+			return productField{Name: name, unit: unit, unitPtr: unitPtr}, true
+		}
+	}
+
+	return productField{}, false
+}
+
+// IsUnit menandakan field milik satuan produk, bukan kolom tabel products
+func (f productField) IsUnit() bool {
+	return f.unit > 0
+}
+
+// target mengembalikan pointer ke nilai field. Untuk field satuan, satuan dicari
+// berdasarkan SortOrder dan dibuat jika create bernilai true.
+func (f productField) target(p *models.Product, create bool) interface{} {
+	if !f.IsUnit() {
+		return f.ptr(p)
+	}
+
+	for index := range p.Units {
+		if p.Units[index].SortOrder == f.unit {
+			return f.unitPtr(&p.Units[index])
+		}
+	}
+
+	if !create {
+		return nil
+	}
+
+	p.Units = append(p.Units, models.ProductUnit{SortOrder: f.unit, Conversion: 1})
+
+	return f.unitPtr(&p.Units[len(p.Units)-1])
+}
+
+// Value mengembalikan nilai field dalam bentuk teks untuk dibandingkan
+func (f productField) Value(p *models.Product) string {
+	switch v := f.target(p, false).(type) {
+	case *string:
+		return *v
+	case *int:
+		return strconv.Itoa(*v)
+	case *decimal.Decimal:
+		return v.String()
+	case *[]models.Category:
+		return (&models.Product{Categories: *v}).CategoryNames()
+	}
+
+	return ""
+}
+
+// Set memvalidasi nilai sel sesuai tipe field lalu mengisinya ke produk
+func (f productField) Set(p *models.Product, raw string) error {
+	switch v := f.target(p, true).(type) {
+	case *string:
+		*v = raw
+	case *int:
+		i, err := parseInt(raw)
+		if err != nil {
+			return err
+		}
+		*v = i
+	case *decimal.Decimal:
+		d, err := parseDecimal(raw)
+		if err != nil {
+			return err
+		}
+		*v = d
+	case *[]models.Category:
+		*v = nil
+		if raw != "" {
+			*v = []models.Category{{Name: raw}}
+		}
+	}
+
+	return nil
+}
+
+// ProductImporter meng-import produk dari spreadsheet POS dengan semantik upsert berdasarkan ID produk
+type ProductImporter struct {
+	DB      *gorm.DB
+	Mapping *Mapping
+	DryRun  bool // Jika true, tidak ada perubahan yang ditulis ke database
+}
+
+// NewProductImporter membuat ProductImporter baru
+func NewProductImporter(db *gorm.DB, mapping *Mapping, dryRun bool) *ProductImporter {
+	return &ProductImporter{DB: db, Mapping: mapping, DryRun: dryRun}
+}
+
+// Import membaca file Excel lalu membuat produk baru atau memperbarui produk yang sudah ada
+func (i *ProductImporter) Import(filePath string) (*Report, error) {
+	f, err := excelize.OpenFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuka file Excel: %w", err)
+	}
+
+	// Gunakan sheet dari mapping, atau sheet pertama
+	sheetName := i.Mapping.Sheet
+	if sheetName == "" {
+		sheetName = f.GetSheetName(1)
+	}
+	if sheetName == "" || f.GetSheetIndex(sheetName) == 0 {
+		return nil, fmt.Errorf("sheet tidak ditemukan dalam file Excel")
+	}
+
+	rows := f.GetRows(sheetName)
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("sheet %s kosong", sheetName)
+	}
+
+	columns, err := i.Mapping.resolve(rows[0])
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{File: filePath, DryRun: i.DryRun, StartedAt: time.Now()}
+
+	for index, row := range rows[1:] {
+		if isBlankRow(row) {
+			continue
+		}
+
+		line := index + 2
+		product, cellErrors := parseProductRow(row, columns)
+		if len(cellErrors) > 0 {
+			report.add(RowResult{Row: line, ProductID: product.ID, Name: product.Name, Action: ActionFailed, Errors: cellErrors})
+			continue
+		}
+
+		report.add(i.importRow(line, product, columns))
+	}
+
+	report.FinishedAt = time.Now()
+
+	return report, nil
+}
+
+// importRow membandingkan satu baris dengan data di database lalu menyimpannya jika bukan dry-run
+func (i *ProductImporter) importRow(line int, product models.Product, columns []resolvedColumn) RowResult {
+	result := RowResult{Row: line, ProductID: product.ID, Name: product.Name}
+
+	var existing models.Product
+	err := i.DB.Unscoped().Preload("Categories").Preload("Units").Where("id = ?", product.ID).First(&existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return result.fail(err)
+	}
+
+	if err := i.resolveCategories(&product); err != nil {
+		return result.fail(err)
+	}
+
+	if err := i.resolveTaxClass(&product); err != nil {
+		return result.fail(err)
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		result.Action = ActionCreated
+		if !i.DryRun {
+			product.Sku = slug.Make(fmt.Sprintf("%s-%s", product.CategoryNames(), product.Name))
+			product.Slug = product.Sku
+			product.Status = 1
+			if err := i.DB.Create(&product).Error; err != nil {
+				return result.fail(err)
+			}
+		}
+		return result
+	}
+
+	result.Changes = diffProduct(&existing, &product, columns)
+	if len(result.Changes) == 0 {
+		result.Action = ActionUnchanged
+		return result
+	}
+
+	result.Action = ActionUpdated
+	if !i.DryRun {
+		fields := make([]string, 0, len(result.Changes))
+		categoriesChanged, unitsChanged := false, false
+		for _, change := range result.Changes {
+			if change.Field == "Categories" {
+				categoriesChanged = true
+				continue
+			}
+			if field, _ := findProductField(change.Field); field.IsUnit() {
+				unitsChanged = true
+				continue
+			}
+			fields = append(fields, change.Field)
+		}
+
+		if len(fields) > 0 {
+			err := i.DB.Unscoped().Model(&existing).Select(fields).Updates(&product).Error
+			if err != nil {
+				return result.fail(err)
+			}
+		}
+
+		if categoriesChanged {
+			err := i.DB.Model(&existing).Association("Categories").Replace(product.Categories)
+			if err != nil {
+				return result.fail(err)
+			}
+		}
+
+		if unitsChanged {
+			if err := i.syncUnits(&existing, product.Units, mappedUnits(columns)); err != nil {
+				return result.fail(err)
+			}
+		}
+	}
+
+	return result
+}
+
+// syncUnits menyamakan satuan produk di database dengan satuan dari spreadsheet.
+// Satuan dicocokkan berdasarkan nama agar ID satuan yang dipakai keranjang dan order tetap sama.
+// Satuan lama di luar urutan yang dipetakan (SortOrder > maxUnit) tidak dihapus.
+func (i *ProductImporter) syncUnits(existing *models.Product, units []models.ProductUnit, maxUnit int) error {
+	return i.DB.Transaction(func(tx *gorm.DB) error {
+		kept := map[string]bool{}
+		for _, unit := range units {
+			kept[unit.Name] = true
+		}
+
+		for _, old := range existing.Units {
+			if !kept[old.Name] && old.SortOrder <= maxUnit {
+				if err := tx.Delete(&old).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, unit := range units {
+			unit.ProductID = existing.ID
+			if old, ok := existing.FindUnit(unit.Name); ok {
+				unit.ID = old.ID
+				unit.CreatedAt = old.CreatedAt
+				if err := tx.Save(&unit).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := tx.Create(&unit).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// resolveCategories mengganti nama kategori dari spreadsheet dengan kategori di database.
+// Kategori yang belum ada dibuat, kecuali saat dry-run.
+func (i *ProductImporter) resolveCategories(product *models.Product) error {
+	var categoryModel models.Category
+	for index, category := range product.Categories {
+		var existing models.Category
+		err := i.DB.Where("slug = ?", slug.Make(category.Name)).First(&existing).Error
+		if err == nil {
+			product.Categories[index] = existing
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if i.DryRun {
+			continue
+		}
+
+		created, err := categoryModel.FindOrCreateByName(i.DB, category.Name)
+		if err != nil {
+			return err
+		}
+		product.Categories[index] = *created
+	}
+
+	return nil
+}
+
+// resolveTaxClass mengganti kode kelas pajak dari spreadsheet dengan ID kelas pajak di database
+func (i *ProductImporter) resolveTaxClass(product *models.Product) error {
+	if product.TaxClassID == "" {
+		return nil
+	}
+
+	var taxClassModel models.TaxClass
+	taxClass, err := taxClassModel.FindByCode(i.DB, product.TaxClassID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("kelas pajak %q tidak dikenal", product.TaxClassID)
+	}
+	if err != nil {
+		return err
+	}
+	product.TaxClassID = taxClass.ID
+
+	return nil
+}
+
+// mappedUnits mengembalikan urutan satuan terbesar yang dipetakan ke spreadsheet
+func mappedUnits(columns []resolvedColumn) int {
+	max := 0
+	for _, column := range columns {
+		if column.field.unit > max {
+			max = column.field.unit
+		}
+	}
+
+	return max
+}
+
+// diffProduct mengembalikan field dari spreadsheet yang nilainya berbeda dengan data di database
+func diffProduct(old *models.Product, new *models.Product, columns []resolvedColumn) []FieldChange {
+	var changes []FieldChange
+	for _, column := range columns {
+		if column.Field == "ID" {
+			continue
+		}
+
+		oldValue, newValue := column.field.Value(old), column.field.Value(new)
+		if oldValue != newValue {
+			changes = append(changes, FieldChange{Field: column.Field, Old: oldValue, New: newValue})
+		}
+	}
+
+	return changes
+}
+
+// parseProductRow mengubah satu baris spreadsheet menjadi models.Product dan memvalidasi setiap sel
+func parseProductRow(row []string, columns []resolvedColumn) (models.Product, []CellError) {
+	var product models.Product
+	var cellErrors []CellError
+
+	for _, column := range columns {
+		raw := ""
+		if column.Index < len(row) {
+			raw = strings.TrimSpace(row[column.Index])
+		}
+
+		if raw == "" && column.Required {
+			cellErrors = append(cellErrors, CellError{Column: column.Header, Value: raw, Message: "wajib diisi"})
+			continue
+		}
+
+		if err := column.field.Set(&product, raw); err != nil {
+			cellErrors = append(cellErrors, CellError{Column: column.Header, Value: raw, Message: err.Error()})
+		}
+	}
+
+	return product, append(cellErrors, normalizeUnits(&product, columns)...)
+}
+
+// normalizeUnits membuang satuan tanpa nama dan menolak nama satuan ganda dalam satu produk
+func normalizeUnits(product *models.Product, columns []resolvedColumn) []CellError {
+	var cellErrors []CellError
+	units := product.Units[:0]
+	seen := map[string]bool{}
+	for _, unit := range product.Units {
+		if unit.Name == "" {
+			continue
+		}
+
+		if seen[unit.Name] {
+			header := fmt.Sprintf("Unit%d.Name", unit.SortOrder)
+			for _, column := range columns {
+				if column.Field == header {
+					header = column.Header
+				}
+			}
+			cellErrors = append(cellErrors, CellError{Column: header, Value: unit.Name, Message: "nama satuan sudah dipakai"})
+			continue
+		}
+
+		if unit.Conversion <= 0 {
+			unit.Conversion = 1
+		}
+
+		seen[unit.Name] = true
+		units = append(units, unit)
+	}
+	product.Units = units
+
+	return cellErrors
+}
+
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseInt mengubah isi sel menjadi integer. Sel kosong bernilai 0, dan angka
+// seperti "12.0" dari Excel diterima selama tidak memiliki pecahan.
+func parseInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	if i, err := strconv.Atoi(s); err == nil {
+		return i, nil
+	}
+
+	d, err := decimal.NewFromString(s)
+	if err != nil || !d.Equal(d.Truncate(0)) {
+		return 0, fmt.Errorf("%q bukan %s", s, TypeInt)
+	}
+
+	return int(d.IntPart()), nil
+}
+
+// parseDecimal mengubah isi sel menjadi decimal.Decimal. Sel kosong bernilai 0.
+// Nilai dibulatkan 2 digit sesuai kolom decimal(16,2) agar sisa float dari Excel
+// tidak terbaca sebagai perubahan setiap kali import diulang.
+func parseDecimal(s string) (decimal.Decimal, error) {
+	if s == "" {
+		return decimal.Zero, nil
+	}
+
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("%q bukan angka %s", s, TypeDecimal)
+	}
+
+	return d.Round(2), nil
+}
@@ -0,0 +1,159 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Aksi yang dilakukan importer terhadap satu baris spreadsheet
+const (
+	ActionCreated   = "created"
+	ActionUpdated   = "updated"
+	ActionUnchanged = "unchanged"
+	ActionFailed    = "failed"
+)
+
+// FieldChange adalah perubahan nilai satu field produk
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// CellError adalah kesalahan validasi pada satu sel
+type CellError struct {
+	Column  string
+	Value   string
+	Message string
+}
+
+// RowResult adalah hasil import satu baris spreadsheet
+type RowResult struct {
+	Row       int
+	ProductID string
+	Name      string
+	Action    string
+	Changes   []FieldChange
+	Errors    []CellError
+}
+
+// fail menandai baris gagal disimpan karena error dari database
+func (r RowResult) fail(err error) RowResult {
+	r.Action = ActionFailed
+	r.Errors = append(r.Errors, CellError{Message: err.Error()})
+	return r
+}
+
+// ErrorMessage menggabungkan semua kesalahan pada baris menjadi satu teks
+func (r RowResult) ErrorMessage() string {
+	messages := make([]string, 0, len(r.Errors))
+	for _, cellError := range r.Errors {
+		if cellError.Column == "" {
+			messages = append(messages, cellError.Message)
+			continue
+		}
+		messages = append(messages, fmt.Sprintf("%s: %s", cellError.Column, cellError.Message))
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Report merangkum hasil satu kali import
+type Report struct {
+	File       string
+	DryRun     bool
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Rows       []RowResult
+	Created    int
+	Updated    int
+	Unchanged  int
+	Failed     int
+}
+
+func (r *Report) add(result RowResult) {
+	switch result.Action {
+	case ActionCreated:
+		r.Created++
+	case ActionUpdated:
+		r.Updated++
+	case ActionUnchanged:
+		r.Unchanged++
+	case ActionFailed:
+		r.Failed++
+	}
+
+	r.Rows = append(r.Rows, result)
+}
+
+// WriteDetails menuliskan setiap baris yang dibuat, diubah, atau gagal beserta perubahan per field
+func (r *Report) WriteDetails(w io.Writer) {
+	for _, row := range r.Rows {
+		switch row.Action {
+		case ActionCreated:
+			fmt.Fprintf(w, "[baris %d] CREATE %s %s\n", row.Row, row.ProductID, row.Name)
+		case ActionUpdated:
+			fmt.Fprintf(w, "[baris %d] UPDATE %s %s\n", row.Row, row.ProductID, row.Name)
+			for _, change := range row.Changes {
+				fmt.Fprintf(w, "    %-12s %s -> %s\n", change.Field, change.Old, change.New)
+			}
+		case ActionFailed:
+			fmt.Fprintf(w, "[baris %d] FAILED %s: %s\n", row.Row, row.ProductID, row.ErrorMessage())
+		}
+	}
+}
+
+// WriteSummary menuliskan ringkasan jumlah baris per aksi
+func (r *Report) WriteSummary(w io.Writer) {
+	mode := "import"
+	if r.DryRun {
+		mode = "dry-run"
+	}
+
+	fmt.Fprintf(w, "File      : %s (%s)\n", r.File, mode)
+	fmt.Fprintf(w, "Waktu     : %s - %s\n", r.StartedAt.Format("2006-01-02 15:04:05"), r.FinishedAt.Format("15:04:05"))
+	fmt.Fprintf(w, "Created   : %d\n", r.Created)
+	fmt.Fprintf(w, "Updated   : %d\n", r.Updated)
+	fmt.Fprintf(w, "Unchanged : %d\n", r.Unchanged)
+	fmt.Fprintf(w, "Failed    : %d\n", r.Failed)
+}
+
+// Save menyimpan ringkasan dan detail import ke file
+func (r *Report) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r.WriteSummary(f)
+	fmt.Fprintln(f)
+	r.WriteDetails(f)
+
+	return nil
+}
+
+// SaveErrors menyimpan setiap kesalahan per baris dan per sel ke file CSV
+func (r *Report) SaveErrors(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"row", "product_id", "column", "value", "message"})
+	for _, row := range r.Rows {
+		for _, cellError := range row.Errors {
+			w.Write([]string{strconv.Itoa(row.Row), row.ProductID, cellError.Column, cellError.Value, cellError.Message})
+		}
+	}
+	w.Flush()
+
+	return w.Error()
+}
@@ -0,0 +1,96 @@
+package money
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// Money adalah nilai uang dalam rupiah. Semua perhitungan memakai decimal, bukan float, dan hasil
+// yang dibayar pelanggan dibulatkan dengan Round ke rupiah penuh.
+//
+// Money disimpan ke kolom decimal seperti decimal.Decimal karena Scan dan Value diturunkan dari
+// decimal.Decimal.
+type Money struct {
+	decimal.Decimal
+}
+
+// Zero adalah nol rupiah
+var Zero = Money{decimal.Zero}
+
+var hundred = decimal.NewFromInt(100)
+
+// New membuat Money dari decimal
+func New(value decimal.Decimal) Money {
+	return Money{value}
+}
+
+// FromInt membuat Money dari jumlah rupiah
+func FromInt(value int64) Money {
+	return Money{decimal.NewFromInt(value)}
+}
+
+// Parse membuat Money dari teks angka, misalnya gross_amount notifikasi payment gateway "10000.00"
+func Parse(value string) (Money, error) {
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return Zero, err
+	}
+
+	return Money{d}, nil
+}
+
+// Add menjumlahkan dua nilai uang
+func (m Money) Add(other Money) Money {
+	return Money{m.Decimal.Add(other.Decimal)}
+}
+
+// Sub mengurangi nilai uang dengan other
+func (m Money) Sub(other Money) Money {
+	return Money{m.Decimal.Sub(other.Decimal)}
+}
+
+// Times mengalikan harga satuan dengan qty
+func (m Money) Times(qty int) Money {
+	return Money{m.Decimal.Mul(decimal.NewFromInt(int64(qty)))}
+}
+
+// Percent menghitung rate persen dari nilai ini, tanpa pembulatan
+func (m Money) Percent(rate decimal.Decimal) Money {
+	return Money{m.Decimal.Mul(rate).Div(hundred)}
+}
+
+// PercentOfGross menghitung bagian rate persen dari nilai yang sudah termasuk rate tersebut,
+// misalnya PPN di dalam harga, tanpa pembulatan
+func (m Money) PercentOfGross(rate decimal.Decimal) Money {
+	return Money{m.Decimal.Mul(rate).Div(hundred.Add(rate))}
+}
+
+// Per membagi nilai uang dengan qty dan membulatkannya ke sen. Dipakai untuk harga satuan
+// sebelum pajak yang hanya ditampilkan, bukan ditagihkan.
+func (m Money) Per(qty int) Money {
+	return Money{m.Decimal.Div(decimal.NewFromInt(int64(qty))).Round(2)}
+}
+
+// Round membulatkan ke rupiah penuh, setengah rupiah dibulatkan menjauhi nol
+func (m Money) Round() Money {
+	return Money{m.Decimal.Round(0)}
+}
+
+// Int64 mengembalikan jumlah rupiah setelah dibulatkan, misalnya untuk gross_amount payment gateway
+func (m Money) Int64() int64 {
+	return m.Round().IntPart()
+}
+
+// Equal menandakan kedua nilai uang sama
+func (m Money) Equal(other Money) bool {
+	return m.Decimal.Equal(other.Decimal)
+}
+
+// LessThan menandakan nilai ini lebih kecil dari other
+func (m Money) LessThan(other Money) bool {
+	return m.Decimal.LessThan(other.Decimal)
+}
+
+// MarshalJSON menulis Money sebagai angka JSON agar bisa langsung dihitung di JavaScript
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.Decimal.String()), nil
+}
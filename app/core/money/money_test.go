@@ -0,0 +1,46 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+	}{
+		{"1040.54", 1041},
+		{"1040.49", 1040},
+		{"0.5", 1},
+		{"-0.5", -1},
+		{"13325.00", 13325},
+	}
+
+	for _, tt := range tests {
+		value, err := Parse(tt.value)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.value, err)
+		}
+
+		if got := value.Int64(); got != tt.want {
+			t.Errorf("Round(%s) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestPercent(t *testing.T) {
+	rate := decimal.NewFromInt(11)
+
+	if got := FromInt(10000).Percent(rate); !got.Equal(FromInt(1100)) {
+		t.Errorf("Percent = %s, want 1100", got)
+	}
+	// PPN di dalam harga 11100 adalah 11100 * 11 / 111
+	if got := FromInt(11100).PercentOfGross(rate); !got.Equal(FromInt(1100)) {
+		t.Errorf("PercentOfGross = %s, want 1100", got)
+	}
+	if got := FromInt(10000).Per(3); got.String() != "3333.33" {
+		t.Errorf("Per = %s, want 3333.33", got)
+	}
+}
@@ -0,0 +1,148 @@
+package payment
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/midtrans/midtrans-go"
+	"github.com/midtrans/midtrans-go/coreapi"
+	"github.com/midtrans/midtrans-go/snap"
+
+	"github.com/gieart87/gotoko/app/models"
+)
+
+// MidtransGateway mengimplementasikan PaymentGateway menggunakan Midtrans Snap dan Core API
+type MidtransGateway struct {
+	ServerKey     string                   // Server key Midtrans
+	Environment   midtrans.EnvironmentType // Sandbox atau Production
+	SkipSignature bool                     // Lewati validasi signature (hanya untuk development)
+}
+
+// NewMidtransGateway membuat gateway Midtrans dari variabel lingkungan
+func NewMidtransGateway() *MidtransGateway {
+	environment := midtrans.Sandbox
+	if os.Getenv("MIDTRANS_ENV") == "production" {
+		environment = midtrans.Production
+	}
+
+	return &MidtransGateway{
+		ServerKey:     os.Getenv("API_MIDTRANS_SERVER_KEY"),
+		Environment:   environment,
+		SkipSignature: os.Getenv("APP_ENV") == "development" || os.Getenv("MIDTRANS_SKIP_SIGNATURE") == "true",
+	}
+}
+
+// CreateTransaction membuat transaksi Snap dan mengembalikan URL pembayaran
+func (m *MidtransGateway) CreateTransaction(req TransactionRequest) (*Transaction, error) {
+	var client snap.Client
+	client.New(m.ServerKey, m.Environment)
+
+	snapRequest := &snap.Request{
+		TransactionDetails: midtrans.TransactionDetails{
+			OrderID:  req.OrderID,
+			GrossAmt: req.GrossAmount,
+		},
+		CustomerDetail: &midtrans.CustomerDetails{
+			FName: req.FirstName,
+			LName: req.LastName,
+			Email: req.Email,
+			Phone: req.Phone,
+		},
+		EnabledPayments: snap.AllSnapPaymentType,
+		Expiry:          snapExpiry(req.ExpiresAt, time.Now()),
+	}
+
+	snapResponse, err := client.CreateTransaction(snapRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Transaction{
+		Token:       snapResponse.Token,
+		RedirectURL: snapResponse.RedirectURL,
+	}, nil
+}
+
+// snapExpiry membuat batas waktu pembayaran Snap dari expiresAt. Durasi dibulatkan ke bawah dalam menit agar
+// pembayaran ditutup Midtrans sebelum reservasi stok dilepas. Nil berarti memakai batas bawaan Midtrans.
+func snapExpiry(expiresAt time.Time, now time.Time) *snap.ExpiryDetails {
+	if expiresAt.IsZero() {
+		return nil
+	}
+
+	minutes := int64(expiresAt.Sub(now) / time.Minute)
+	if minutes < 1 {
+		minutes = 1
+	}
+
+	return &snap.ExpiryDetails{
+		StartTime: now.Format("2006-01-02 15:04:05 -0700"),
+		Unit:      "minute",
+		Duration:  minutes,
+	}
+}
+
+// ParseNotification membaca payload notifikasi Midtrans dan memvalidasi signature key
+func (m *MidtransGateway) ParseNotification(r *http.Request) (*models.MidtransNotification, error) {
+	var notification models.MidtransNotification
+
+	err := json.NewDecoder(r.Body).Decode(&notification)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.SkipSignature {
+		return &notification, nil
+	}
+
+	err = verifySignature(&notification, m.ServerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &notification, nil
+}
+
+// Status mengambil status transaksi dari Midtrans Core API
+func (m *MidtransGateway) Status(orderID string) (*models.MidtransNotification, error) {
+	var client coreapi.Client
+	client.New(m.ServerKey, m.Environment)
+
+	response, err := client.CheckTransaction(orderID)
+	if err != nil {
+		if err.StatusCode == http.StatusNotFound {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	// Struktur respons status Midtrans memiliki tag JSON yang sama dengan notifikasi
+	jsonData, _ := json.Marshal(response)
+
+	var notification models.MidtransNotification
+	if err := json.Unmarshal(jsonData, &notification); err != nil {
+		return nil, err
+	}
+
+	return &notification, nil
+}
+
+// Refund mengajukan refund transaksi ke Midtrans
+func (m *MidtransGateway) Refund(orderID string, amount int64, reason string) error {
+	var client coreapi.Client
+	client.New(m.ServerKey, m.Environment)
+
+	_, err := client.RefundTransaction(orderID, &coreapi.RefundReq{
+		RefundKey: fmt.Sprintf("%s-refund-%d", orderID, time.Now().Unix()),
+		Amount:    amount,
+		Reason:    reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package payment
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSnapExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 17, 10, 0, 0, 0, time.FixedZone("WITA", 8*3600))
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		want      int64
+	}{
+		{"batas waktu reservasi", now.Add(24 * time.Hour), 1440},
+		{"dibulatkan ke bawah", now.Add(90*time.Minute + 59*time.Second), 90},
+		{"minimal satu menit", now.Add(10 * time.Second), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expiry := snapExpiry(tt.expiresAt, now)
+			if expiry == nil || expiry.Unit != "minute" || expiry.Duration != tt.want {
+				t.Fatalf("snapExpiry = %+v, want %d minute", expiry, tt.want)
+			}
+			if expiry.StartTime != "2026-10-17 10:00:00 +0800" {
+				t.Errorf("StartTime = %s", expiry.StartTime)
+			}
+		})
+	}
+
+	if expiry := snapExpiry(time.Time{}, now); expiry != nil {
+		t.Errorf("snapExpiry tanpa batas waktu = %+v, want nil", expiry)
+	}
+}
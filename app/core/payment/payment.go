@@ -0,0 +1,84 @@
+package payment
+
+import (
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gieart87/gotoko/app/models"
+)
+
+// Nama payment gateway yang dapat dipilih melalui konfigurasi PAYMENT_GATEWAY
+const (
+	GatewayMidtrans  = "midtrans"
+	GatewaySimulator = "simulator"
+)
+
+// ErrInvalidSignature dikembalikan jika signature notifikasi tidak valid
+var ErrInvalidSignature = errors.New("invalid signature key")
+
+// ErrTransactionNotFound dikembalikan jika transaksi tidak dikenal oleh gateway
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// TransactionRequest berisi data yang dibutuhkan untuk membuat transaksi pembayaran
+type TransactionRequest struct {
+	OrderID     string
+	GrossAmount int64
+	FirstName   string
+	LastName    string
+	Email       string
+	Phone       string
+	ExpiresAt   time.Time // Batas waktu pembayaran, sama dengan Order.PaymentDue dan batas reservasi stok
+}
+
+// Transaction adalah hasil pembuatan transaksi pada payment gateway
+type Transaction struct {
+	Token       string // Token transaksi dari gateway
+	RedirectURL string // Halaman pembayaran untuk pelanggan
+}
+
+// PaymentGateway adalah abstraksi payment gateway yang digunakan saat checkout dan webhook
+type PaymentGateway interface {
+	// CreateTransaction membuat transaksi baru dan mengembalikan URL pembayaran
+	CreateTransaction(req TransactionRequest) (*Transaction, error)
+	// ParseNotification membaca dan memverifikasi notifikasi yang dikirim gateway
+	ParseNotification(r *http.Request) (*models.MidtransNotification, error)
+	// Status mengambil status transaksi terbaru berdasarkan order ID
+	Status(orderID string) (*models.MidtransNotification, error)
+	// Refund mengembalikan sebagian atau seluruh dana transaksi
+	Refund(orderID string, amount int64, reason string) error
+}
+
+// NewGateway membuat PaymentGateway sesuai nama yang dikonfigurasi.
+// Nama yang tidak dikenal akan menggunakan Midtrans sebagai default.
+func NewGateway(name string, appPort string) PaymentGateway {
+	switch name {
+	case GatewaySimulator:
+		log.Printf("Payment gateway: simulator (offline)")
+		return NewSimulator(appPort)
+	default:
+		return NewMidtransGateway()
+	}
+}
+
+// SignatureKey menghitung signature notifikasi dengan format Midtrans:
+// SHA512(order_id + status_code + gross_amount + server_key)
+func SignatureKey(orderID string, statusCode string, grossAmount string, serverKey string) string {
+	sha512Value := sha512.New()
+	sha512Value.Write([]byte(orderID + statusCode + grossAmount + serverKey))
+
+	return fmt.Sprintf("%x", sha512Value.Sum(nil))
+}
+
+// verifySignature memastikan signature pada notifikasi sesuai dengan server key
+func verifySignature(payload *models.MidtransNotification, serverKey string) error {
+	signatureKey := SignatureKey(payload.OrderID, payload.StatusCode, payload.GrossAmount, serverKey)
+	if signatureKey != payload.SignatureKey {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
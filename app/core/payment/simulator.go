@@ -0,0 +1,234 @@
+package payment
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gieart87/gotoko/app/models"
+)
+
+// Status transaksi yang dapat dipilih pada halaman simulator
+var SimulatorStatuses = []string{"settlement", "pending", "deny", "cancel", "expire"}
+
+// statusCodes memetakan status transaksi ke status_code yang dikirim Midtrans
+var statusCodes = map[string]string{
+	"capture":        "200",
+	"settlement":     "200",
+	"pending":        "201",
+	"deny":           "202",
+	"cancel":         "200",
+	"expire":         "407",
+	"refund":         "200",
+	"partial_refund": "200",
+}
+
+// SimulatedTransaction adalah transaksi yang disimpan oleh Simulator
+type SimulatedTransaction struct {
+	OrderID           string
+	TransactionID     string
+	GrossAmount       int64
+	RefundAmount      int64
+	TransactionStatus string
+	TransactionTime   time.Time
+	ExpiresAt         time.Time // Nol berarti tanpa batas waktu
+	CustomerName      string
+	CustomerEmail     string
+}
+
+// Simulator adalah PaymentGateway lokal yang menampilkan halaman pembayaran palsu dan
+// mengirim notifikasi bertanda tangan ke /payment/notification seperti Midtrans.
+type Simulator struct {
+	ServerKey       string       // Kunci untuk menandatangani notifikasi
+	NotificationURL string       // URL webhook tujuan notifikasi, selalu aplikasi lokal
+	PageURL         string       // Path dasar halaman pembayaran simulator
+	Client          *http.Client // Klien HTTP untuk mengirim notifikasi
+
+	mu           sync.Mutex
+	transactions map[string]*SimulatedTransaction
+}
+
+// NewSimulator membuat Simulator yang mengirim notifikasi ke aplikasi lokal pada appPort. APP_URL sengaja
+// tidak dipakai agar notifikasi palsu tidak pernah terkirim ke server produksi.
+func NewSimulator(appPort string) *Simulator {
+	serverKey := os.Getenv("API_MIDTRANS_SERVER_KEY")
+	if serverKey == "" {
+		serverKey = "simulator-server-key"
+	}
+
+	return &Simulator{
+		ServerKey:       serverKey,
+		NotificationURL: "http://localhost:" + appPort + "/payment/notification",
+		PageURL:         "/payment/simulator/",
+		Client:          &http.Client{Timeout: 10 * time.Second},
+		transactions:    map[string]*SimulatedTransaction{},
+	}
+}
+
+// CreateTransaction menyimpan transaksi berstatus pending dan mengarahkan ke halaman simulator
+func (s *Simulator) CreateTransaction(req TransactionRequest) (*Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.transactions[req.OrderID] = &SimulatedTransaction{
+		OrderID:           req.OrderID,
+		TransactionID:     uuid.New().String(),
+		GrossAmount:       req.GrossAmount,
+		TransactionStatus: "pending",
+		TransactionTime:   time.Now(),
+		ExpiresAt:         req.ExpiresAt,
+		CustomerName:      strings.TrimSpace(req.FirstName + " " + req.LastName),
+		CustomerEmail:     req.Email,
+	}
+
+	return &Transaction{
+		Token:       "SIM-" + req.OrderID,
+		RedirectURL: s.PageURL + req.OrderID,
+	}, nil
+}
+
+// ParseNotification membaca notifikasi dan selalu memvalidasi signature key
+func (s *Simulator) ParseNotification(r *http.Request) (*models.MidtransNotification, error) {
+	var notification models.MidtransNotification
+
+	err := json.NewDecoder(r.Body).Decode(&notification)
+	if err != nil {
+		return nil, err
+	}
+
+	err = verifySignature(&notification, s.ServerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &notification, nil
+}
+
+// Status mengembalikan status transaksi yang tersimpan dalam format notifikasi
+func (s *Simulator) Status(orderID string) (*models.MidtransNotification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transaction, ok := s.transactions[orderID]
+	if !ok {
+		return nil, ErrTransactionNotFound
+	}
+
+	return s.notification(transaction), nil
+}
+
+// Refund menandai transaksi sebagai refund dan mengirim notifikasinya
+func (s *Simulator) Refund(orderID string, amount int64, reason string) error {
+	s.mu.Lock()
+	transaction, ok := s.transactions[orderID]
+	if !ok {
+		s.mu.Unlock()
+		return ErrTransactionNotFound
+	}
+
+	transaction.RefundAmount += amount
+	transaction.TransactionStatus = "partial_refund"
+	if transaction.RefundAmount >= transaction.GrossAmount {
+		transaction.TransactionStatus = "refund"
+	}
+	notification := s.notification(transaction)
+	s.mu.Unlock()
+
+	return s.send(notification)
+}
+
+// Transaction mengembalikan salinan transaksi yang tersimpan untuk ditampilkan di halaman simulator
+func (s *Simulator) Transaction(orderID string) (SimulatedTransaction, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transaction, ok := s.transactions[orderID]
+	if !ok {
+		return SimulatedTransaction{}, false
+	}
+
+	return *transaction, true
+}
+
+// ErrTransactionExpired dikembalikan simulator jika transaksi dibayar setelah batas waktunya, seperti VA Midtrans yang ditutup
+var ErrTransactionExpired = errors.New("transaction expired")
+
+// Complete mengubah status transaksi dan mengirim notifikasi bertanda tangan ke webhook
+func (s *Simulator) Complete(orderID string, status string) error {
+	if _, ok := statusCodes[status]; !ok {
+		return fmt.Errorf("unknown transaction status: %s", status)
+	}
+
+	s.mu.Lock()
+	transaction, ok := s.transactions[orderID]
+	if !ok {
+		s.mu.Unlock()
+		return ErrTransactionNotFound
+	}
+	if status == "settlement" && !transaction.ExpiresAt.IsZero() && time.Now().After(transaction.ExpiresAt) {
+		s.mu.Unlock()
+		return ErrTransactionExpired
+	}
+
+	transaction.TransactionStatus = status
+	transaction.TransactionTime = time.Now()
+	notification := s.notification(transaction)
+	s.mu.Unlock()
+
+	return s.send(notification)
+}
+
+// notification menyusun notifikasi bergaya Midtrans dari transaksi simulator
+func (s *Simulator) notification(transaction *SimulatedTransaction) *models.MidtransNotification {
+	grossAmount := fmt.Sprintf("%d.00", transaction.GrossAmount)
+	statusCode := statusCodes[transaction.TransactionStatus]
+
+	notification := &models.MidtransNotification{
+		TransactionTime:   transaction.TransactionTime.Format("2006-01-02 15:04:05"),
+		TransactionStatus: transaction.TransactionStatus,
+		TransactionID:     transaction.TransactionID,
+		StatusMessage:     "simulator notification",
+		StatusCode:        statusCode,
+		PaymentType:       "bank_transfer",
+		OrderID:           transaction.OrderID,
+		MerchantID:        "SIMULATOR",
+		GrossAmount:       grossAmount,
+		FraudStatus:       "accept",
+		Currency:          "IDR",
+		SignatureKey:      SignatureKey(transaction.OrderID, statusCode, grossAmount, s.ServerKey),
+	}
+
+	if transaction.TransactionStatus == "settlement" {
+		notification.SettlementTime = notification.TransactionTime
+	}
+
+	return notification
+}
+
+// send mengirim notifikasi ke webhook aplikasi
+func (s *Simulator) send(notification *models.MidtransNotification) error {
+	jsonData, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.Client.Post(s.NotificationURL, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("notification rejected with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,219 @@
+package pricing
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/gieart87/gotoko/app/core/money"
+	"github.com/gieart87/gotoko/app/models"
+)
+
+// TierRetail adalah label harga dasar satuan ketika tidak ada aturan harga yang berlaku
+const TierRetail = "Eceran"
+
+// Tier adalah satu tingkat harga: berlaku mulai MinQty satuan dengan harga Price per satuan.
+// Tingkat harga flash sale hanya berlaku sampai MaxQty satuan (sisa kuota) dan sampai waktu EndsAt.
+type Tier struct {
+	MinQty      int         `json:"min_qty"`
+	MaxQty      int         `json:"max_qty,omitempty"` // Nol berarti tanpa batas
+	Price       money.Money `json:"price"`
+	Label       string      `json:"label"`
+	FlashSaleID string      `json:"flash_sale_id,omitempty"`
+	EndsAt      *time.Time  `json:"ends_at,omitempty"`
+}
+
+// Applies menandakan tingkat harga berlaku untuk qty satuan
+func (t Tier) Applies(qty int) bool {
+	return t.MinQty <= qty && (t.MaxQty == 0 || qty <= t.MaxQty)
+}
+
+// Engine menghitung harga satuan produk dari harga eceran, harga kelompok pelanggan (models.CustomerGroup),
+// aturan harga (models.PriceRule) dan flash sale (models.FlashSale) yang aktif
+type Engine struct {
+	DB *gorm.DB
+}
+
+// NewEngine membuat Engine baru
+func NewEngine(db *gorm.DB) *Engine {
+	return &Engine{DB: db}
+}
+
+// PriceList mengambil aturan harga yang aktif saat ini untuk pelanggan. User nil berarti tamu.
+func (e *Engine) PriceList(user *models.User) (*PriceList, error) {
+	var ruleModel models.PriceRule
+	rules, err := ruleModel.GetActiveRules(e.DB, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	var saleModel models.FlashSale
+	sales, err := saleModel.GetActiveFlashSales(e.DB, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	list := &PriceList{db: e.DB, rules: rules, flashSales: sales, categoryIDs: map[string][]string{}}
+	if user == nil || user.PriceGroupID() == "" {
+		return list, nil
+	}
+
+	// Kelompok yang sudah dihapus diperlakukan sama seperti pelanggan tanpa kelompok
+	var groupModel models.CustomerGroup
+	group, err := groupModel.FindByID(e.DB, user.PriceGroupID())
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	list.group = group
+
+	return list, nil
+}
+
+// PriceList adalah daftar aturan harga aktif untuk satu pelanggan. Dipakai untuk menghitung
+// harga beberapa item sekaligus tanpa membaca ulang aturan dari database.
+type PriceList struct {
+	db          *gorm.DB
+	rules       []models.PriceRule
+	flashSales  []models.FlashSale
+	group       *models.CustomerGroup
+	categoryIDs map[string][]string // Kategori produk beserta induknya, per ID produk
+}
+
+// Tiers mengembalikan semua tingkat harga satuan produk, urut dari qty minimum terkecil
+func (l *PriceList) Tiers(product *models.Product, unit *models.ProductUnit) ([]Tier, error) {
+	listPrice := ListPrice(unit)
+	tiers := []Tier{{MinQty: 1, Price: money.New(listPrice), Label: TierRetail}}
+
+	// Harga kelompok pelanggan berlaku mulai 1 satuan
+	if l.group != nil {
+		price := l.group.Price(listPrice, unit.WholesalePrice)
+		if price.LessThan(listPrice) {
+			tiers = append(tiers, Tier{MinQty: 1, Price: money.New(price), Label: l.group.Name})
+		}
+	}
+
+	for _, rule := range l.rules {
+		matched, err := l.matches(&rule, product, unit)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			continue
+		}
+
+		// Aturan grosir tidak berlaku untuk satuan yang belum memiliki harga grosir
+		if rule.Type == models.PriceRuleTypeWholesale && unit.WholesalePrice.IsZero() {
+			continue
+		}
+
+		// Aturan yang tidak lebih murah dari harga eceran tidak pernah terpilih, jadi tidak perlu ditampilkan
+		price := rule.Apply(listPrice, unit.WholesalePrice)
+		if !price.LessThan(listPrice) {
+			continue
+		}
+
+		tiers = append(tiers, Tier{MinQty: rule.MinQty, Price: money.New(price), Label: rule.Name})
+	}
+
+	// Flash sale mengganti harga satuan mulai 1 satuan. Harga lain yang lebih murah tetap menang.
+	if sale := l.FlashSale(product, unit); sale != nil && sale.Price.LessThan(money.New(listPrice)) {
+		endsAt := sale.EndsAt
+		tier := Tier{MinQty: 1, Price: sale.Price, Label: sale.Name, FlashSaleID: sale.ID, EndsAt: &endsAt}
+		if sale.Quota > 0 {
+			tier.MaxQty = sale.Remaining()
+		}
+		tiers = append(tiers, tier)
+	}
+
+	sort.SliceStable(tiers, func(i, j int) bool {
+		return tiers[i].MinQty < tiers[j].MinQty
+	})
+
+	return tiers, nil
+}
+
+// FlashSale mengembalikan flash sale yang sedang berlangsung untuk satuan produk dan kuotanya belum habis,
+// nil jika tidak ada
+func (l *PriceList) FlashSale(product *models.Product, unit *models.ProductUnit) *models.FlashSale {
+	for i, sale := range l.flashSales {
+		if sale.ProductID == product.ID && sale.UnitName == unit.Name && (sale.Quota == 0 || sale.Remaining() > 0) {
+			return &l.flashSales[i]
+		}
+	}
+
+	return nil
+}
+
+// Group mengembalikan kelompok pelanggan pemilik daftar harga, nil untuk tamu atau pelanggan tanpa kelompok
+func (l *PriceList) Group() *models.CustomerGroup {
+	return l.group
+}
+
+// Quote mengembalikan tingkat harga yang berlaku untuk qty satuan produk
+func (l *PriceList) Quote(product *models.Product, unit *models.ProductUnit, qty int) (Tier, error) {
+	tiers, err := l.Tiers(product, unit)
+	if err != nil {
+		return Tier{}, err
+	}
+
+	return BestTier(tiers, qty), nil
+}
+
+// matches menandakan aturan berlaku untuk produk, satuan dan pelanggan ini
+func (l *PriceList) matches(rule *models.PriceRule, product *models.Product, unit *models.ProductUnit) (bool, error) {
+	if rule.ProductID != "" && rule.ProductID != product.ID {
+		return false, nil
+	}
+	if rule.UnitName != "" && rule.UnitName != unit.Name {
+		return false, nil
+	}
+	if rule.CustomerGroupID != "" && (l.group == nil || rule.CustomerGroupID != l.group.ID) {
+		return false, nil
+	}
+	if rule.CategoryID == "" {
+		return true, nil
+	}
+
+	categoryIDs, ok := l.categoryIDs[product.ID]
+	if !ok {
+		var err error
+		categoryIDs, err = product.GetCategoryIDs(l.db)
+		if err != nil {
+			return false, err
+		}
+		l.categoryIDs[product.ID] = categoryIDs
+	}
+
+	for _, categoryID := range categoryIDs {
+		if categoryID == rule.CategoryID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// BestTier memilih harga termurah dari tingkat harga yang berlaku untuk qty.
+// Tingkat pertama (harga eceran) selalu berlaku.
+func BestTier(tiers []Tier, qty int) Tier {
+	best := tiers[0]
+	for _, tier := range tiers[1:] {
+		if tier.Applies(qty) && tier.Price.LessThan(best.Price) {
+			best = tier
+		}
+	}
+
+	return best
+}
+
+// ListPrice adalah harga eceran satuan. Jika harga eceran belum diisi, harga grosir yang dipakai.
+func ListPrice(unit *models.ProductUnit) decimal.Decimal {
+	if unit.RetailPrice.IsZero() {
+		return unit.WholesalePrice
+	}
+
+	return unit.RetailPrice
+}
@@ -0,0 +1,42 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/gieart87/gotoko/app/core/money"
+)
+
+func TestBestTier(t *testing.T) {
+	tiers := []Tier{
+		{MinQty: 1, Price: money.FromInt(10000), Label: "Eceran"},
+		{MinQty: 6, Price: money.FromInt(9000), Label: "Grosir 6+"},
+		{MinQty: 12, Price: money.FromInt(8500), Label: "Grosir 12+"},
+		{MinQty: 1, MaxQty: 2, Price: money.FromInt(8000), Label: "Flash sale", FlashSaleID: "fs"},
+		{MinQty: 24, Price: money.FromInt(10500), Label: "Lebih mahal dari eceran"},
+	}
+
+	tests := []struct {
+		name  string
+		tiers []Tier
+		qty   int
+		want  string
+	}{
+		{"flash sale dalam batas qty", tiers, 1, "Flash sale"},
+		{"flash sale di batas maksimum", tiers, 2, "Flash sale"},
+		{"melewati batas flash sale kembali ke eceran", tiers, 3, "Eceran"},
+		{"minimal qty grosir terpenuhi", tiers, 6, "Grosir 6+"},
+		{"grosir termurah yang berlaku", tiers, 12, "Grosir 12+"},
+		{"tingkat yang lebih mahal tidak dipilih", tiers, 24, "Grosir 12+"},
+		{"hanya harga eceran", tiers[:1], 100, "Eceran"},
+		{"harga sama tetap eceran", []Tier{tiers[0], {MinQty: 2, Price: money.FromInt(10000), Label: "Sama"}}, 2, "Eceran"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BestTier(tt.tiers, tt.qty)
+			if got.Label != tt.want {
+				t.Errorf("BestTier(qty %d) = %s, want %s", tt.qty, got.Label, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package report
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/gieart87/gotoko/app/consts"
+	"github.com/gieart87/gotoko/app/core/money"
+	"github.com/gieart87/gotoko/app/models"
+)
+
+// Pengelompokan laporan margin
+const (
+	GroupProduct  = "product"
+	GroupCategory = "category"
+	GroupSupplier = "supplier"
+	GroupDay      = "day"
+	GroupMonth    = "month"
+)
+
+// MarginGroups adalah pengelompokan laporan margin beserta labelnya, dipakai di halaman admin
+var MarginGroups = map[string]string{
+	GroupProduct:  "Produk",
+	GroupCategory: "Kategori",
+	GroupSupplier: "Supplier",
+	GroupDay:      "Harian",
+	GroupMonth:    "Bulanan",
+}
+
+// DateLayout adalah format tanggal filter laporan, sama dengan input type=date
+const DateLayout = "2006-01-02"
+
+// MarginFilter membatasi order yang dihitung: tanggal order From sampai To (keduanya termasuk)
+type MarginFilter struct {
+	From    time.Time
+	To      time.Time
+	GroupBy string
+}
+
+// MarginRow adalah pendapatan, HPP dan margin satu kelompok. Pendapatan dihitung dari total item
+// sebelum PPN dan sesudah potongan voucher, ongkos kirim tidak termasuk.
+type MarginRow struct {
+	Key     string
+	Label   string
+	Qty     int
+	Revenue money.Money
+	Cost    money.Money
+}
+
+// Margin adalah pendapatan dikurangi HPP
+func (r MarginRow) Margin() money.Money {
+	return r.Revenue.Sub(r.Cost)
+}
+
+// MarginPercent adalah margin terhadap pendapatan dalam persen, dibulatkan 2 angka di belakang koma
+func (r MarginRow) MarginPercent() decimal.Decimal {
+	if r.Revenue.IsZero() {
+		return decimal.Zero
+	}
+
+	return r.Margin().Decimal.Mul(decimal.NewFromInt(100)).Div(r.Revenue.Decimal).Round(2)
+}
+
+func (r *MarginRow) add(item *models.OrderItem) {
+	r.Qty += item.Qty
+	r.Revenue = r.Revenue.Add(item.BaseTotal)
+	r.Cost = r.Cost.Add(item.CostTotal())
+}
+
+// MarginReport adalah laporan margin kotor dari order yang sudah dibayar dan tidak dibatalkan.
+// Produk dengan beberapa kategori dihitung di setiap kategorinya, sedangkan Total dihitung sekali per item.
+type MarginReport struct {
+	Filter      MarginFilter
+	Rows        []MarginRow
+	Total       MarginRow
+	MissingCost int // Jumlah item tanpa harga pokok, margin item tersebut sama dengan pendapatannya
+}
+
+// Margin menyusun laporan margin sesuai filter
+func Margin(db *gorm.DB, filter MarginFilter) (*MarginReport, error) {
+	if _, ok := MarginGroups[filter.GroupBy]; !ok {
+		return nil, fmt.Errorf("pengelompokan %q tidak dikenal", filter.GroupBy)
+	}
+	if filter.To.Before(filter.From) {
+		return nil, errors.New("tanggal akhir harus setelah tanggal awal")
+	}
+
+	orders := db.Model(&models.Order{}).Select("id").
+		Where("payment_status = ? AND status <> ?", consts.OrderPaymentStatusPaid, consts.OrderStatusCancelled).
+		Where("order_date >= ? AND order_date < ?", filter.From, filter.To.AddDate(0, 0, 1))
+
+	var items []models.OrderItem
+	err := db.Debug().Preload("Order").Preload("Product.Categories").
+		Where("order_id IN (?)", orders).
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MarginReport{Filter: filter, Total: MarginRow{Label: "Total"}}
+	rows := map[string]*MarginRow{}
+	for i := range items {
+		item := &items[i]
+		report.Total.add(item)
+		if item.CostPrice.IsZero() {
+			report.MissingCost++
+		}
+
+		for _, group := range groupsOf(item, filter.GroupBy) {
+			row, ok := rows[group.Key]
+			if !ok {
+				row = &MarginRow{Key: group.Key, Label: group.Label}
+				rows[group.Key] = row
+			}
+			row.add(item)
+		}
+	}
+
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		// Laporan per periode diurutkan menurut waktu, selain itu dari pendapatan terbesar
+		if filter.GroupBy == GroupDay || filter.GroupBy == GroupMonth {
+			return report.Rows[i].Key < report.Rows[j].Key
+		}
+		if !report.Rows[i].Revenue.Equal(report.Rows[j].Revenue) {
+			return report.Rows[j].Revenue.LessThan(report.Rows[i].Revenue)
+		}
+		return report.Rows[i].Label < report.Rows[j].Label
+	})
+
+	return report, nil
+}
+
+// groupsOf mengembalikan kelompok item. Nama produk diambil dari data item karena produknya bisa sudah dihapus.
+func groupsOf(item *models.OrderItem, groupBy string) []MarginRow {
+	switch groupBy {
+	case GroupCategory:
+		if len(item.Product.Categories) == 0 {
+			return []MarginRow{{Key: "", Label: "Tanpa kategori"}}
+		}
+		groups := make([]MarginRow, len(item.Product.Categories))
+		for i, category := range item.Product.Categories {
+			groups[i] = MarginRow{Key: category.ID, Label: category.Name}
+		}
+		return groups
+	case GroupSupplier:
+		if item.Product.Supplier == "" {
+			return []MarginRow{{Key: "", Label: "Tanpa supplier"}}
+		}
+		return []MarginRow{{Key: item.Product.Supplier, Label: item.Product.Supplier}}
+	case GroupDay:
+		day := item.Order.OrderDate.Format(DateLayout)
+		return []MarginRow{{Key: day, Label: day}}
+	case GroupMonth:
+		month := item.Order.OrderDate.Format("2006-01")
+		return []MarginRow{{Key: month, Label: month}}
+	default:
+		return []MarginRow{{Key: item.ProductID, Label: item.ProductID + " " + item.Name}}
+	}
+}
+
+// WriteCSV menulis laporan sebagai CSV agar bisa dibuka di Excel. Nilai uang ditulis apa adanya tanpa pemisah ribuan.
+func (r *MarginReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	rows := [][]string{
+		{"Kelompok", "Qty", "Pendapatan", "HPP", "Margin", "Margin %"},
+	}
+	for _, row := range append(r.Rows, r.Total) {
+		rows = append(rows, []string{
+			row.Label,
+			fmt.Sprint(row.Qty),
+			row.Revenue.StringFixed(2),
+			row.Cost.StringFixed(2),
+			row.Margin().StringFixed(2),
+			row.MarginPercent().StringFixed(2),
+		})
+	}
+
+	err := writer.WriteAll(rows)
+	if err != nil {
+		return err
+	}
+
+	return writer.Error()
+}
@@ -0,0 +1,220 @@
+package shipping
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gieart87/gotoko/app/models"
+)
+
+// BiteshipProvider mengimplementasikan ShippingProvider menggunakan API Biteship
+type BiteshipProvider struct {
+	APIKey  string       // Token API Biteship
+	BaseURL string       // URL dasar API Biteship
+	Client  *http.Client // Klien HTTP yang digunakan untuk memanggil API
+}
+
+// NewBiteshipProvider membuat provider Biteship dengan token API yang diberikan
+func NewBiteshipProvider(apiKey string) *BiteshipProvider {
+	return &BiteshipProvider{
+		APIKey:  apiKey,
+		BaseURL: "https://api.biteship.com/v1",
+		Client:  &http.Client{},
+	}
+}
+
+// IsTestingMode menandakan apakah token API yang digunakan adalah token sandbox
+func (b *BiteshipProvider) IsTestingMode() bool {
+	return strings.Contains(b.APIKey, "biteship_test")
+}
+
+// Rates mengirim permintaan POST ke API Biteship untuk menghitung biaya pengiriman
+func (b *BiteshipProvider) Rates(params RateParams) ([]models.Pricing, error) {
+	modeLabel := "🚀 PRODUCTION"
+	if b.IsTestingMode() {
+		modeLabel = "🧪 TESTING"
+	}
+
+	log.Printf("🚚 BITESHIP API REQUEST (%s MODE):", modeLabel)
+	log.Printf("   Origin Area ID: %s", params.Origin)
+	log.Printf("   Destination Area ID: %s", params.Destination)
+	log.Printf("   Couriers: %s", params.Couriers)
+	log.Printf("   Weight: %d grams", params.Weight)
+
+	// Validate input parameters
+	if params.Origin == "" {
+		return nil, fmt.Errorf("origin area ID cannot be empty")
+	}
+	if params.Destination == "" {
+		return nil, fmt.Errorf("destination area ID cannot be empty")
+	}
+	if params.Couriers == "" {
+		return nil, fmt.Errorf("couriers cannot be empty")
+	}
+
+	// Membuat payload data permintaan sesuai format API Biteship
+	payload := models.CourierRequest{
+		OriginAreaID:      params.Origin,
+		DestinationAreaID: params.Destination,
+		Couriers:          params.Couriers,
+		Items: []models.Item{
+			{
+				Name:        "Cart Items",                        // Nama item default
+				Description: "Combined items from shopping cart", // Deskripsi item default
+				Value:       100000,                              // Nilai item contoh
+				Length:      10,                                  // Panjang item dalam cm
+				Width:       10,                                  // Lebar item dalam cm
+				Height:      10,                                  // Tinggi item dalam cm
+				Weight:      params.Weight,                       // Berat item dari parameter
+				Quantity:    1,                                   // Jumlah item default
+			},
+		},
+	}
+
+	body, err := b.do("POST", "/rates/couriers", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("📦 BITESHIP API RESPONSE: %s", string(body))
+
+	// Mengurai data JSON dari respons
+	var response models.CourierResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ BITESHIP PARSED DATA: success=%v origin=%+v destination=%+v pricing=%d",
+		response.Success, response.Origin, response.Destination, len(response.Pricing))
+
+	// Memeriksa keberhasilan respons dari API
+	if !response.Success {
+		return nil, errors.New(response.Message)
+	}
+
+	return response.Pricing, nil
+}
+
+// InstantRates menghitung biaya pengiriman instan menggunakan API Biteship
+// berdasarkan OriginCoordinate dan DestinationCoordinate.
+func (b *BiteshipProvider) InstantRates(params RateParams) ([]models.Pricing, error) {
+	// Membuat payload untuk permintaan API yang berisi data pengiriman
+	payload := models.CourierInstantRequest{
+		OriginLatitude:       params.OriginCoordinate.Latitude,
+		OriginLongitude:      params.OriginCoordinate.Longitude,
+		DestinationLatitude:  params.DestinationCoordinate.Latitude,
+		DestinationLongitude: params.DestinationCoordinate.Longitude,
+		Couriers:             "grab,gojek",
+		Items: []models.Item{
+			{
+				Name:        "Shoes",
+				Description: "Black colored size 45",
+				Value:       199000,
+				Length:      30,
+				Width:       15,
+				Height:      20,
+				Weight:      200,
+				Quantity:    2,
+			},
+		},
+	}
+
+	body, err := b.do("POST", "/rates/couriers", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	// Mengurai respons JSON ke dalam struktur data `CourierResponse`
+	var response models.CourierResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("API returned error: %s", response.Message)
+	}
+
+	return response.Pricing, nil
+}
+
+// CreateOrder membuat pesanan baru menggunakan API Biteship
+func (b *BiteshipProvider) CreateOrder(params models.OrderParams) (*models.OrderResponse, error) {
+	body, err := b.do("POST", "/orders", params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Mengurai data JSON dari respons ke dalam struktur data `OrderResponse`
+	var response models.OrderResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if !response.Success {
+		return nil, errors.New(response.Message)
+	}
+
+	return &response, nil
+}
+
+// Track mengambil data pelacakan pengiriman berdasarkan nomor resi
+func (b *BiteshipProvider) Track(waybillID string) (*models.TrackingResponse, error) {
+	body, err := b.do("GET", "/trackings/"+waybillID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response models.TrackingResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &response, nil
+}
+
+// do mengirim permintaan ke API Biteship dan mengembalikan body respons jika status 200 OK
+func (b *BiteshipProvider) do(method string, path string, payload interface{}) ([]byte, error) {
+	reqBody := &bytes.Buffer{}
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		reqBody = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequest(method, b.BaseURL+path, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+b.APIKey)
+
+	resp, err := b.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error: %s", string(body))
+	}
+
+	return body, nil
+}
@@ -0,0 +1,81 @@
+package shipping
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/gieart87/gotoko/app/models"
+)
+
+// Nama provider pengiriman yang dapat dipilih melalui konfigurasi SHIPPING_PROVIDER
+const (
+	ProviderBiteship = "biteship"
+	ProviderFake     = "fake"
+)
+
+// Koordinat Toko Shafirda - Jl. KH. Harun Nafsi No.106, Loa Janan Ilir
+const (
+	StoreLatitude  = -0.526313085327813
+	StoreLongitude = 117.13666900992393
+)
+
+// Coordinate adalah titik lokasi untuk pengiriman instan
+type Coordinate struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// StoreCoordinate adalah lokasi toko, asal pengiriman instan
+var StoreCoordinate = Coordinate{Latitude: StoreLatitude, Longitude: StoreLongitude}
+
+// ParseCoordinate membaca latitude dan longitude dari nilai form
+func ParseCoordinate(latitude, longitude string) (Coordinate, error) {
+	lat, err := strconv.ParseFloat(latitude, 64)
+	if err != nil {
+		return Coordinate{}, fmt.Errorf("invalid latitude %q", latitude)
+	}
+
+	lng, err := strconv.ParseFloat(longitude, 64)
+	if err != nil {
+		return Coordinate{}, fmt.Errorf("invalid longitude %q", longitude)
+	}
+
+	return Coordinate{Latitude: lat, Longitude: lng}, nil
+}
+
+// RateParams berisi parameter untuk menghitung ongkos kirim. Rates memakai area ID,
+// InstantRates memakai koordinat.
+type RateParams struct {
+	Origin                string     // Area ID asal untuk pengiriman reguler
+	Destination           string     // Area ID tujuan untuk pengiriman reguler
+	OriginCoordinate      Coordinate // Koordinat asal untuk pengiriman instan
+	DestinationCoordinate Coordinate // Koordinat tujuan untuk pengiriman instan
+	Weight                int        // Berat total dalam gram
+	Couriers              string     // Daftar kurir dipisahkan koma, misalnya "jne,grab"
+}
+
+// ShippingProvider adalah abstraksi layanan pengiriman yang digunakan saat checkout
+type ShippingProvider interface {
+	// Rates menghitung ongkos kirim reguler berdasarkan area asal dan tujuan
+	Rates(params RateParams) ([]models.Pricing, error)
+	// InstantRates menghitung ongkos kirim instan berdasarkan koordinat tujuan
+	InstantRates(params RateParams) ([]models.Pricing, error)
+	// CreateOrder membuat pesanan pengiriman pada kurir
+	CreateOrder(params models.OrderParams) (*models.OrderResponse, error)
+	// Track mengambil riwayat pengiriman berdasarkan nomor resi
+	Track(waybillID string) (*models.TrackingResponse, error)
+}
+
+// NewProvider membuat ShippingProvider sesuai nama yang dikonfigurasi.
+// Nama yang tidak dikenal akan menggunakan Biteship sebagai default.
+func NewProvider(name string) ShippingProvider {
+	switch name {
+	case ProviderFake:
+		log.Printf("Shipping provider: fake (offline)")
+		return NewFakeProvider()
+	default:
+		return NewBiteshipProvider(os.Getenv("API_BITESHIP"))
+	}
+}
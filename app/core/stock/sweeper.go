@@ -0,0 +1,96 @@
+package stock
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/gieart87/gotoko/app/consts"
+	"github.com/gieart87/gotoko/app/models"
+)
+
+// DefaultHoldDuration adalah lama stok ditahan untuk order yang belum dibayar, sama dengan batas waktu pembayaran
+const DefaultHoldDuration = 24 * time.Hour
+
+// DefaultSweepInterval adalah jeda antar pemeriksaan reservasi stok yang kedaluwarsa
+const DefaultSweepInterval = time.Minute
+
+// Sweeper melepas stok yang ditahan order yang dibatalkan atau tidak dibayar sampai reservasinya kedaluwarsa
+type Sweeper struct {
+	DB       *gorm.DB
+	Interval time.Duration
+}
+
+// NewSweeper membuat Sweeper baru
+func NewSweeper(db *gorm.DB, interval time.Duration) *Sweeper {
+	return &Sweeper{DB: db, Interval: interval}
+}
+
+// Start menjalankan Sweep setiap Interval di goroutine terpisah sampai stop ditutup
+func (s *Sweeper) Start(stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(s.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				released, err := s.Sweep(now)
+				if err != nil {
+					log.Printf("Stock sweeper failed: %v", err)
+				}
+				if released > 0 {
+					log.Printf("Stock sweeper released reservations of %d orders", released)
+				}
+			}
+		}
+	}()
+}
+
+// Sweep melepas reservasi yang kedaluwarsa pada waktu now atau ordernya sudah dibatalkan. Order yang masih
+// menunggu pembayaran ketika reservasinya kedaluwarsa dibatalkan dengan status EXPIRED, sehingga notifikasi
+// pembayaran yang datang terlambat tidak lagi diterima. Mengembalikan jumlah order yang reservasinya dilepas.
+func (s *Sweeper) Sweep(now time.Time) (int, error) {
+	orderIDs, err := models.GetExpiredReservationOrderIDs(s.DB, now)
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, orderID := range orderIDs {
+		err = s.DB.Transaction(func(tx *gorm.DB) error {
+			return release(tx, orderID)
+		})
+		if err != nil {
+			return released, err
+		}
+		released++
+	}
+
+	return released, nil
+}
+
+// release melepas reservasi satu order. Order dikunci agar tidak bersamaan dengan notifikasi pembayaran.
+func release(tx *gorm.DB, orderID string) error {
+	orderModel := models.Order{}
+	order, err := orderModel.FindByIDForUpdate(tx, orderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.ReleaseStockReservations(tx, orderID, "Order tidak ditemukan")
+	}
+	if err != nil {
+		return err
+	}
+
+	if order.IsAwaitingPayment() {
+		return order.MarkAsCancelled(tx, consts.OrderPaymentStatusExpired, "Batas waktu pembayaran habis")
+	}
+	if order.Status == consts.OrderStatusCancelled {
+		return models.ReleaseStockReservations(tx, orderID, order.CancellationNote.String)
+	}
+
+	return nil
+}
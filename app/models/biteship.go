@@ -1,5 +1,9 @@
 package models
 
+import (
+	"github.com/gieart87/gotoko/app/core/money"
+)
+
 // Define the request model
 type CourierRequest struct {
 	OriginAreaID      string `json:"origin_area_id"`
@@ -41,10 +45,10 @@ type CourierResponseInstant struct {
 }
 
 type Pricing struct {
-	CourierName        string `json:"courier_name"`
-	CourierServiceName string `json:"courier_service_name"`
-	Duration           string `json:"duration"`
-	Price              int    `json:"price"`
+	CourierName        string      `json:"courier_name"`
+	CourierServiceName string      `json:"courier_service_name"`
+	Duration           string      `json:"duration"`
+	Price              money.Money `json:"price"`
 }
 
 type Location struct {
@@ -63,12 +67,12 @@ type CourierInstantRequest struct {
 }
 
 type PricingInstant struct {
-	Company              string `json:"company"`
-	CourierName          string `json:"courier_name"`
-	CourierServiceName   string `json:"courier_service_name"`
-	Price                int    `json:"price"`
-	Duration             string `json:"duration"`
-	ShipmentDurationUnit string `json:"shipment_duration_unit"`
+	Company              string      `json:"company"`
+	CourierName          string      `json:"courier_name"`
+	CourierServiceName   string      `json:"courier_service_name"`
+	Price                money.Money `json:"price"`
+	Duration             string      `json:"duration"`
+	ShipmentDurationUnit string      `json:"shipment_duration_unit"`
 }
 
 type OrderParams struct {
@@ -232,12 +236,7 @@ type TrackingResponse struct {
 		ContactName string `json:"contact_name"`
 		Address     string `json:"address"`
 	} `json:"destination"`
-	History []struct {
-		Note        string `json:"note"`
-		ServiceType string `json:"service_type"`
-		Status      string `json:"status"`
-		UpdatedAt   string `json:"updated_at"`
-	} `json:"history"`
+	History []TrackingHistory `json:"history"`
 	Link    string `json:"link"`
 	OrderID string `json:"order_id"`
 	Origin  struct {
@@ -246,3 +245,10 @@ type TrackingResponse struct {
 	} `json:"origin"`
 	Status string `json:"status"`
 }
+
+type TrackingHistory struct {
+	Note        string `json:"note"`
+	ServiceType string `json:"service_type"`
+	Status      string `json:"status"`
+	UpdatedAt   string `json:"updated_at"`
+}
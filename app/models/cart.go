@@ -1,19 +1,26 @@
 package models
 
 import (
+	"errors"
+	"time"
+
 	"github.com/shopspring/decimal"
+
+	"github.com/gieart87/gotoko/app/core/money"
 	"gorm.io/gorm"
 )
 
 type Cart struct {
 	ID              string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	UserID          string `gorm:"size:36;default:null;uniqueIndex:idx_carts_user_id"` // Pemilik keranjang, NULL untuk keranjang tamu
 	CartItems       []CartItem
-	BaseTotalPrice  decimal.Decimal `gorm:"type:decimal(16,2)"`
-	TaxAmount       decimal.Decimal `gorm:"type:decimal(16,2)"`
+	BaseTotalPrice  money.Money     `gorm:"type:decimal(16,2)"`
+	TaxAmount       money.Money     `gorm:"type:decimal(16,2)"`
 	TaxPercent      decimal.Decimal `gorm:"type:decimal(10,2)"`
-	DiscountAmount  decimal.Decimal `gorm:"type:decimal(16,2)"`
+	DiscountAmount  money.Money     `gorm:"type:decimal(16,2)"`
 	DiscountPercent decimal.Decimal `gorm:"type:decimal(10,2)"`
-	GrandTotal      decimal.Decimal `gorm:"type:decimal(16,2)"`
+	GrandTotal      money.Money     `gorm:"type:decimal(16,2)"`
+	VoucherCode     string          `gorm:"size:50"` // Voucher yang dipakai, kosong jika tidak ada
 	TotalWeight     int             `gorm:"-"`
 }
 
@@ -31,15 +38,27 @@ func (c *Cart) GetCart(db *gorm.DB, cartID string) (*Cart, error) {
 	return &cart, nil
 }
 
+// FindByUserID mengambil keranjang milik pelanggan tanpa itemnya
+func (c *Cart) FindByUserID(db *gorm.DB, userID string) (*Cart, error) {
+	var cart Cart
+
+	err := db.Debug().Model(Cart{}).Where("user_id = ?", userID).First(&cart).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &cart, nil
+}
+
 func (c *Cart) CreateCart(db *gorm.DB, cartID string) (*Cart, error) {
 	cart := &Cart{
 		ID:              cartID,
-		BaseTotalPrice:  decimal.NewFromInt(0),
-		TaxAmount:       decimal.NewFromInt(0),
+		BaseTotalPrice:  money.Zero,
+		TaxAmount:       money.Zero,
 		TaxPercent:      decimal.NewFromInt(0),
-		DiscountAmount:  decimal.NewFromInt(0),
+		DiscountAmount:  money.Zero,
 		DiscountPercent: decimal.NewFromInt(0),
-		GrandTotal:      decimal.NewFromInt(0),
+		GrandTotal:      money.Zero,
 	}
 
 	err := db.Debug().Create(&cart).Error
@@ -50,34 +69,68 @@ func (c *Cart) CreateCart(db *gorm.DB, cartID string) (*Cart, error) {
 	return cart, nil
 }
 
+// CalculateCart menghitung ulang potongan voucher dan pajak setiap item dengan tarif dan mode pajak
+// yang berlaku, lalu menjumlahkan total keranjang
 func (c *Cart) CalculateCart(db *gorm.DB, cartID string) (*Cart, error) {
-	cartBaseTotalPrice := 0.0
-	cartTaxAmount := 0.0
-	cartDiscountAmount := 0.0
-	cartGrandTotal := 0.0
+	cartBaseTotalPrice := money.Zero
+	cartTaxAmount := money.Zero
+	cartTaxPercent := decimal.Zero
+	cartDiscountAmount := money.Zero
+	cartDiscountPercent := decimal.Zero
+	cartGrandTotal := money.Zero
+
+	voucher, discounts, err := c.voucherDiscounts(db)
+	if err != nil {
+		return nil, err
+	}
+	if voucher != nil && voucher.Type == VoucherTypePercent {
+		cartDiscountPercent = voucher.Value
+	}
 
 	for _, item := range c.CartItems {
-		itemBaseTotal, _ := item.BaseTotal.Float64()
-		itemTaxAmount, _ := item.TaxAmount.Float64()
-		itemSubTotalTaxAmount := itemTaxAmount * float64(item.Qty)
-		itemDiscountAmount, _ := item.DiscountAmount.Float64()
-		itemSubTotalDiscountAmount := itemDiscountAmount * float64(item.Qty)
-		itemSubTotal, _ := item.SubTotal.Float64()
+		discount, ok := discounts[item.ID]
+		if !ok {
+			discount = money.Zero
+		}
 
-		cartBaseTotalPrice += itemBaseTotal
-		cartTaxAmount += itemSubTotalTaxAmount
-		cartDiscountAmount += itemSubTotalDiscountAmount
-		cartGrandTotal += itemSubTotal
-	}
+		line, err := productTaxLine(db, &item.Product, item.Pricenew, item.Qty, discount)
+		if err != nil {
+			return nil, err
+		}
+
+		if !line.SubTotal.Equal(item.SubTotal) || !line.TaxAmount.Equal(item.TaxAmount) || !line.TaxPercent.Equal(item.TaxPercent) || !discount.Equal(item.DiscountAmount) {
+			err = db.Debug().Model(&item).
+				Select("base_price", "base_total", "tax_percent", "tax_amount", "discount_percent", "discount_amount", "sub_total").
+				Updates(CartItem{BasePrice: line.BasePrice, BaseTotal: line.BaseTotal, TaxPercent: line.TaxPercent, TaxAmount: line.TaxAmount, DiscountPercent: cartDiscountPercent, DiscountAmount: discount, SubTotal: line.SubTotal}).Error
+			if err != nil {
+				return nil, err
+			}
+		}
 
-	var updateCart, cart Cart
+		// Persen pajak keranjang adalah tarif tertinggi dari itemnya, rincian per tarif ada di setiap item
+		if line.TaxPercent.GreaterThan(cartTaxPercent) {
+			cartTaxPercent = line.TaxPercent
+		}
+
+		cartBaseTotalPrice = cartBaseTotalPrice.Add(line.BaseTotal)
+		cartTaxAmount = cartTaxAmount.Add(line.TaxAmount)
+		cartDiscountAmount = cartDiscountAmount.Add(discount)
+		cartGrandTotal = cartGrandTotal.Add(line.SubTotal)
+	}
 
-	updateCart.BaseTotalPrice = decimal.NewFromFloat(cartBaseTotalPrice)
-	updateCart.TaxAmount = decimal.NewFromFloat(cartTaxAmount)
-	updateCart.DiscountAmount = decimal.NewFromFloat(cartDiscountAmount)
-	updateCart.GrandTotal = decimal.NewFromFloat(cartGrandTotal)
+	var cart Cart
 
-	err := db.Debug().First(&cart, "id = ?", c.ID).Updates(updateCart).Error
+	err = db.Debug().First(&cart, "id = ?", c.ID).
+		Select("base_total_price", "tax_amount", "tax_percent", "discount_amount", "discount_percent", "grand_total", "voucher_code").
+		Updates(Cart{
+			BaseTotalPrice:  cartBaseTotalPrice,
+			TaxAmount:       cartTaxAmount,
+			TaxPercent:      cartTaxPercent,
+			DiscountAmount:  cartDiscountAmount,
+			DiscountPercent: cartDiscountPercent,
+			GrandTotal:      cartGrandTotal,
+			VoucherCode:     c.VoucherCode,
+		}).Error
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +138,45 @@ func (c *Cart) CalculateCart(db *gorm.DB, cartID string) (*Cart, error) {
 	return &cart, nil
 }
 
+// voucherDiscounts menghitung potongan voucher keranjang per ID item. Voucher yang sudah tidak ada,
+// tidak aktif atau tidak lagi memberi potongan dilepas dari keranjang.
+func (c *Cart) voucherDiscounts(db *gorm.DB) (*Voucher, map[string]money.Money, error) {
+	if c.VoucherCode == "" {
+		return nil, nil, nil
+	}
+
+	var voucherModel Voucher
+	voucher, err := voucherModel.FindByCode(db, c.VoucherCode)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+	if err != nil || !voucher.IsActiveAt(time.Now()) {
+		c.VoucherCode = ""
+		return nil, nil, nil
+	}
+
+	discounts, err := voucher.Allocate(db, c.CartItems)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(discounts) == 0 {
+		c.VoucherCode = ""
+		return nil, nil, nil
+	}
+
+	return voucher, discounts, nil
+}
+
+// ApplyVoucher memasang voucher pada keranjang. Potongannya dihitung oleh CalculateCart.
+func (c *Cart) ApplyVoucher(db *gorm.DB, voucher *Voucher) error {
+	return db.Debug().Model(&Cart{}).Where("id = ?", c.ID).Update("voucher_code", voucher.Code).Error
+}
+
+// RemoveVoucher melepas voucher dari keranjang
+func (c *Cart) RemoveVoucher(db *gorm.DB) error {
+	return db.Debug().Model(&Cart{}).Where("id = ?", c.ID).Update("voucher_code", "").Error
+}
+
 func (c *Cart) AddItem(db *gorm.DB, item CartItem) (*CartItem, error) {
 	var existItem, updateItem CartItem
 	var product Product
@@ -94,12 +186,6 @@ func (c *Cart) AddItem(db *gorm.DB, item CartItem) (*CartItem, error) {
 		return nil, err
 	}
 
-	basePrice := float64(item.Pricenew)
-
-	taxAmount := GetTaxAmount(basePrice)
-
-	discountAmount := 0.0
-
 	// Modified logic: Check for existing item with SAME product_id AND SAME unit
 	err = db.Debug().Model(CartItem{}).
 		Where("cart_id = ?", c.ID).
@@ -108,17 +194,21 @@ func (c *Cart) AddItem(db *gorm.DB, item CartItem) (*CartItem, error) {
 		First(&existItem).Error
 
 	if err != nil {
-		// Item with this product+unit combination doesn't exist, create new
-		subTotal := float64(item.Qty) * (basePrice + taxAmount - discountAmount)
+		// Item with this product+unit combination doesn't exist, create new.
+		// Potongan voucher dihitung ulang oleh CalculateCart.
+		line, err := productTaxLine(db, &product, item.Pricenew, item.Qty, money.Zero)
+		if err != nil {
+			return nil, err
+		}
 
 		item.CartID = c.ID
-		item.BasePrice = decimal.NewFromFloat(float64(item.Pricenew))
-		item.BaseTotal = decimal.NewFromFloat(basePrice * float64(item.Qty))
-		item.TaxPercent = decimal.NewFromFloat(GetTaxPercent())
-		item.TaxAmount = decimal.NewFromFloat(taxAmount)
-		item.DiscountPercent = decimal.NewFromFloat(0)
-		item.DiscountAmount = decimal.NewFromFloat(discountAmount)
-		item.SubTotal = decimal.NewFromFloat(subTotal)
+		item.BasePrice = line.BasePrice
+		item.BaseTotal = line.BaseTotal
+		item.TaxPercent = line.TaxPercent
+		item.TaxAmount = line.TaxAmount
+		item.DiscountPercent = decimal.Zero
+		item.DiscountAmount = money.Zero
+		item.SubTotal = line.SubTotal
 
 		err = db.Debug().Create(&item).Error
 		if err != nil {
@@ -130,13 +220,17 @@ func (c *Cart) AddItem(db *gorm.DB, item CartItem) (*CartItem, error) {
 
 	// Item with same product+unit exists, update quantity
 	updateItem.Qty = existItem.Qty + item.Qty
-	updateItem.Pricenew = existItem.Pricenew
-	updateItem.BaseTotal = decimal.NewFromFloat(basePrice * float64(updateItem.Qty))
-
-	subTotal := float64(updateItem.Qty) * (basePrice + taxAmount - discountAmount)
-	updateItem.SubTotal = decimal.NewFromFloat(subTotal)
+	line, err := productTaxLine(db, &product, existItem.Pricenew, updateItem.Qty, money.Zero)
+	if err != nil {
+		return nil, err
+	}
+	updateItem.BaseTotal = line.BaseTotal
+	updateItem.TaxAmount = line.TaxAmount
+	updateItem.SubTotal = line.SubTotal
 
-	err = db.Debug().First(&existItem, "id = ?", existItem.ID).Updates(updateItem).Error
+	err = db.Debug().First(&existItem, "id = ?", existItem.ID).
+		Select("qty", "base_total", "tax_amount", "sub_total").
+		Updates(updateItem).Error
 	if err != nil {
 		return nil, err
 	}
@@ -161,7 +255,7 @@ func (c *Cart) GetItems(db *gorm.DB, cartID string) ([]CartItem, error) {
 func (c *Cart) UpdateItemQty(db *gorm.DB, itemID string, qty int) (*CartItem, error) {
 	var existItem, updateItem CartItem
 
-	err := db.Debug().Model(CartItem{}).
+	err := db.Debug().Preload("Product").Model(CartItem{}).
 		Where("id = ?", itemID).
 		First(&existItem).Error
 	if err != nil {
@@ -169,18 +263,57 @@ func (c *Cart) UpdateItemQty(db *gorm.DB, itemID string, qty int) (*CartItem, er
 	}
 
 	// Gunakan Pricenew yang sudah di-update dari controller, bukan product.Price
-	basePrice := float64(existItem.Pricenew)
-	taxAmount := GetTaxAmount(basePrice)
-	discountAmount := 0.0
+	line, err := productTaxLine(db, &existItem.Product, existItem.Pricenew, qty, money.Zero)
+	if err != nil {
+		return nil, err
+	}
 
 	updateItem.Qty = qty
-	updateItem.BasePrice = decimal.NewFromFloat(basePrice)
-	updateItem.BaseTotal = decimal.NewFromFloat(basePrice * float64(updateItem.Qty))
+	updateItem.BasePrice = line.BasePrice
+	updateItem.BaseTotal = line.BaseTotal
+	updateItem.TaxPercent = line.TaxPercent
+	updateItem.TaxAmount = line.TaxAmount
+	updateItem.SubTotal = line.SubTotal
+
+	err = db.Debug().Model(&existItem).
+		Select("qty", "base_price", "base_total", "tax_percent", "tax_amount", "sub_total").
+		Updates(updateItem).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &existItem, nil
+}
 
-	subTotal := float64(updateItem.Qty) * (basePrice + taxAmount - discountAmount)
-	updateItem.SubTotal = decimal.NewFromFloat(subTotal)
+// UpdateItemPrice mengganti harga satuan item dengan hasil perhitungan aturan harga lalu menghitung ulang totalnya.
+// flashSaleID diisi jika harga tersebut berasal dari flash sale.
+func (c *Cart) UpdateItemPrice(db *gorm.DB, itemID string, price money.Money, tier string, flashSaleID string) (*CartItem, error) {
+	var existItem, updateItem CartItem
 
-	err = db.Debug().First(&existItem, "id = ?", existItem.ID).Updates(updateItem).Error
+	err := db.Debug().Preload("Product").Model(CartItem{}).
+		Where("id = ?", itemID).
+		First(&existItem).Error
+	if err != nil {
+		return nil, err
+	}
+
+	line, err := productTaxLine(db, &existItem.Product, price, existItem.Qty, money.Zero)
+	if err != nil {
+		return nil, err
+	}
+
+	updateItem.Pricenew = price
+	updateItem.PriceTier = tier
+	updateItem.FlashSaleID = flashSaleID
+	updateItem.BasePrice = line.BasePrice
+	updateItem.BaseTotal = line.BaseTotal
+	updateItem.TaxPercent = line.TaxPercent
+	updateItem.TaxAmount = line.TaxAmount
+	updateItem.SubTotal = line.SubTotal
+
+	err = db.Debug().Model(&existItem).
+		Select("pricenew", "price_tier", "flash_sale_id", "base_price", "base_total", "tax_percent", "tax_amount", "sub_total").
+		Updates(updateItem).Error
 	if err != nil {
 		return nil, err
 	}
@@ -188,11 +321,12 @@ func (c *Cart) UpdateItemQty(db *gorm.DB, itemID string, qty int) (*CartItem, er
 	return &existItem, nil
 }
 
+// RemoveItemByID menghapus item keranjang ini. Item keranjang lain tidak bisa dihapus.
 func (c *Cart) RemoveItemByID(db *gorm.DB, itemID string) error {
 	var err error
 	var item CartItem
 
-	err = db.Debug().Model(&CartItem{}).Where("id = ?", itemID).First(&item).Error
+	err = db.Debug().Model(&CartItem{}).Where("id = ? AND cart_id = ?", itemID, c.ID).First(&item).Error
 	if err != nil {
 		return err
 	}
@@ -205,6 +339,56 @@ func (c *Cart) RemoveItemByID(db *gorm.DB, itemID string) error {
 	return nil
 }
 
+// AssignUser menjadikan keranjang milik pelanggan userID
+func (c *Cart) AssignUser(db *gorm.DB, userID string) error {
+	err := db.Debug().Model(&Cart{}).Where("id = ?", c.ID).Update("user_id", userID).Error
+	if err != nil {
+		return err
+	}
+
+	c.UserID = userID
+	return nil
+}
+
+// MergeCart memindahkan item keranjang lain ke keranjang ini lalu menghapus keranjang tersebut. Item dengan
+// produk dan satuan yang sama dijumlahkan qty-nya, harga dan pajaknya dihitung ulang oleh pemanggil.
+func (c *Cart) MergeCart(db *gorm.DB, other *Cart) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, item := range other.CartItems {
+			_, err := c.AddItem(tx, CartItem{
+				ProductID:     item.ProductID,
+				Qty:           item.Qty,
+				Unit:          item.Unit,
+				ProductUnitID: item.ProductUnitID,
+				PriceTier:     item.PriceTier,
+				FlashSaleID:   item.FlashSaleID,
+				Pricenew:      item.Pricenew,
+			})
+			// Produk yang sudah dihapus tidak ikut dipindahkan
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return c.ClearCart(tx, other.ID)
+	})
+}
+
+// EmptyCart menghapus semua item dan voucher keranjang ini tanpa menghapus keranjangnya, sehingga pemiliknya tetap sama
+func (c *Cart) EmptyCart(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Debug().Where("cart_id = ?", c.ID).Delete(&CartItem{}).Error
+		if err != nil {
+			return err
+		}
+
+		return c.RemoveVoucher(tx)
+	})
+}
+
 func (c *Cart) ClearCart(db *gorm.DB, cartID string) error {
 	err := db.Debug().Where("cart_id = ?", cartID).Delete(&CartItem{}).Error
 	if err != nil {
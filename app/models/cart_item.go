@@ -1,12 +1,15 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
 	"github.com/shopspring/decimal"
+
+	"github.com/gieart87/gotoko/app/core/money"
 )
 
 type CartItem struct {
@@ -16,19 +19,20 @@ type CartItem struct {
 	Product         Product
 	ProductID       string `gorm:"size:36;index"`
 	Qty             int
-	Unit            string          `gorm:"size:50"` // Tambah field untuk menyimpan unit (SATUAN1/2/3)
-	BasePrice       decimal.Decimal `gorm:"type:decimal(16,2)"`
-	BaseTotal       decimal.Decimal `gorm:"type:decimal(16,2)"`
-	TaxAmount       decimal.Decimal `gorm:"type:decimal(16,2)"`
+	Unit            string          `gorm:"size:50"` // Nama satuan yang dipilih
+	ProductUnitID   string          `gorm:"size:36;index"`
+	PriceTier       string          `gorm:"size:100"`      // Label tingkat harga yang dipakai, misalnya Eceran
+	FlashSaleID     string          `gorm:"size:36;index"` // Flash sale yang harganya dipakai, kosong jika bukan harga promo
+	BasePrice       money.Money     `gorm:"type:decimal(16,2)"`
+	BaseTotal       money.Money     `gorm:"type:decimal(16,2)"`
+	TaxAmount       money.Money     `gorm:"type:decimal(16,2)"`
 	TaxPercent      decimal.Decimal `gorm:"type:decimal(10,2)"`
-	DiscountAmount  decimal.Decimal `gorm:"type:decimal(16,2)"`
+	DiscountAmount  money.Money     `gorm:"type:decimal(16,2)"`
 	DiscountPercent decimal.Decimal `gorm:"type:decimal(10,2)"`
-	SubTotal        decimal.Decimal `gorm:"type:decimal(16,2)"`
+	SubTotal        money.Money     `gorm:"type:decimal(16,2)"`
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
-	Pricenew        int
-
-
+	Pricenew        money.Money `gorm:"type:decimal(16,2)"` // Harga jual satuan yang dipakai
 }
 
 func (c *CartItem) BeforeCreate(tx *gorm.DB) error {
@@ -38,3 +42,34 @@ func (c *CartItem) BeforeCreate(tx *gorm.DB) error {
 
 	return nil
 }
+
+// FindByID mencari item keranjang berdasarkan ID tanpa memeriksa keranjangnya
+func (c *CartItem) FindByID(db *gorm.DB, id string) (*CartItem, error) {
+	var item CartItem
+
+	err := db.Debug().Model(&CartItem{}).Where("id = ?", id).First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+// UnitCost mengambil harga pokok satuan item dari data produk saat ini, nol jika produk atau satuannya sudah dihapus
+func (c *CartItem) UnitCost(db *gorm.DB) (money.Money, error) {
+	var productModel Product
+	product, err := productModel.FindByID(db, c.ProductID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return money.Zero, nil
+	}
+	if err != nil {
+		return money.Zero, err
+	}
+
+	unit, ok := product.FindUnitForItem(c.ProductUnitID, c.Unit)
+	if !ok {
+		return money.Zero, nil
+	}
+
+	return money.New(unit.CostPrice), nil
+}
@@ -0,0 +1,35 @@
+package models
+
+import "testing"
+
+func TestCartAssignUserUnique(t *testing.T) {
+	db := newTestDB(t)
+
+	var cartModel Cart
+	first, err := cartModel.CreateCart(db, "C1")
+	if err != nil {
+		t.Fatalf("CreateCart: %v", err)
+	}
+	// Beberapa keranjang tamu tidak bentrok dengan indeks unik pemilik
+	second, err := cartModel.CreateCart(db, "C2")
+	if err != nil {
+		t.Fatalf("CreateCart keranjang tamu kedua: %v", err)
+	}
+
+	if err := first.AssignUser(db, "U1"); err != nil {
+		t.Fatalf("AssignUser: %v", err)
+	}
+	if err := second.AssignUser(db, "U1"); err == nil {
+		t.Fatal("AssignUser keranjang kedua untuk pelanggan yang sama seharusnya ditolak")
+	}
+
+	cart, err := cartModel.FindByUserID(db, "U1")
+	if err != nil || cart.ID != first.ID {
+		t.Fatalf("FindByUserID = %v, %v, want %s", cart, err, first.ID)
+	}
+
+	guest, err := cartModel.GetCart(db, second.ID)
+	if err != nil || guest.UserID != "" {
+		t.Fatalf("GetCart keranjang tamu = %+v, %v", guest, err)
+	}
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gosimple/slug"
+	"gorm.io/gorm"
+)
+
+type Category struct {
+	ID        string     `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	ParentID  string     `gorm:"size:36;index"`
+	Children  []Category `gorm:"foreignKey:ParentID"`
+	Name      string     `gorm:"size:100;not null"`
+	Slug      string     `gorm:"size:100;not null;uniqueIndex"`
+	SortOrder int        `gorm:"default:0"`
+	Image     string     `gorm:"type:text"`
+	Products  []Product  `gorm:"many2many:product_categories;"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt
+}
+
+func (c *Category) BeforeCreate(db *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+
+	if c.Slug == "" {
+		c.Slug = slug.Make(c.Name)
+	}
+
+	return nil
+}
+
+// GetCategories mengambil semua kategori tanpa susunan pohon, urut berdasarkan nama
+func (c *Category) GetCategories(db *gorm.DB) ([]Category, error) {
+	var categories []Category
+
+	err := db.Debug().Model(&Category{}).Order("name asc").Find(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// GetCategoryTree mengembalikan kategori induk beserta seluruh turunannya, diurutkan berdasarkan SortOrder lalu nama
+func (c *Category) GetCategoryTree(db *gorm.DB) ([]Category, error) {
+	var categories []Category
+
+	err := db.Debug().Model(&Category{}).Order("sort_order asc, name asc").Find(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return buildCategoryTree(categories, ""), nil
+}
+
+func buildCategoryTree(categories []Category, parentID string) []Category {
+	var tree []Category
+	for _, category := range categories {
+		if category.ParentID == parentID {
+			category.Children = buildCategoryTree(categories, category.ID)
+			tree = append(tree, category)
+		}
+	}
+
+	return tree
+}
+
+func (c *Category) FindBySlug(db *gorm.DB, slug string) (*Category, error) {
+	var err error
+	var category Category
+
+	err = db.Debug().Model(&Category{}).Where("slug = ?", slug).First(&category).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+// GetAncestors mengembalikan kategori induk dari yang paling atas sampai induk langsung, untuk breadcrumb
+func (c *Category) GetAncestors(db *gorm.DB) ([]Category, error) {
+	var ancestors []Category
+
+	parentID := c.ParentID
+	for parentID != "" && len(ancestors) < 10 {
+		var parent Category
+		err := db.Debug().Model(&Category{}).Where("id = ?", parentID).First(&parent).Error
+		if err != nil {
+			return nil, err
+		}
+
+		ancestors = append([]Category{parent}, ancestors...)
+		parentID = parent.ParentID
+	}
+
+	return ancestors, nil
+}
+
+// GetDescendantIDs mengembalikan ID kategori ini beserta semua sub kategorinya
+func (c *Category) GetDescendantIDs(db *gorm.DB) ([]string, error) {
+	ids := []string{c.ID}
+	parentIDs := []string{c.ID}
+
+	for len(parentIDs) > 0 && len(ids) < 1000 {
+		var childIDs []string
+		err := db.Debug().Model(&Category{}).Where("parent_id IN ?", parentIDs).Pluck("id", &childIDs).Error
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, childIDs...)
+		parentIDs = childIDs
+	}
+
+	return ids, nil
+}
+
+// GetProducts mengambil produk pada kategori ini dan sub kategorinya
+func (c *Category) GetProducts(db *gorm.DB, perPage int, page int) (*[]Product, int64, error) {
+	var err error
+	var products []Product
+	var count int64
+
+	categoryIDs, err := c.GetDescendantIDs(db)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	queryBuilder := db.Debug().Model(&Product{}).Where(
+		"id IN (SELECT product_id FROM product_categories WHERE category_id IN ?)", categoryIDs,
+	)
+
+	err = queryBuilder.Count(&count).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * perPage
+
+	err = queryBuilder.Preload("ProductImages").Preload("Units", orderUnits).Order("stock desc").Limit(perPage).Offset(offset).Find(&products).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return &products, count, nil
+}
+
+// FindOrCreateByName mencari kategori induk berdasarkan slug dari nama, lalu membuatnya jika belum ada
+func (c *Category) FindOrCreateByName(db *gorm.DB, name string) (*Category, error) {
+	name = strings.TrimSpace(name)
+
+	var category Category
+	err := db.Debug().Where(Category{Slug: slug.Make(name)}).Attrs(Category{Name: name}).FirstOrCreate(&category).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gosimple/slug"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Kolom harga satuan yang menjadi dasar harga kelompok pelanggan
+const (
+	CustomerGroupPriceRetail    = "retail"    // Harga eceran (RetailPrice)
+	CustomerGroupPriceWholesale = "wholesale" // Harga grosir (WholesalePrice) tanpa syarat qty
+)
+
+// CustomerGroupPriceColumns adalah kolom harga beserta labelnya, dipakai di form admin
+var CustomerGroupPriceColumns = map[string]string{
+	CustomerGroupPriceRetail:    "Harga eceran",
+	CustomerGroupPriceWholesale: "Harga grosir",
+}
+
+// CustomerGroup adalah kelompok pelanggan, misalnya eceran, reseller atau mitra warung.
+// Harga kelompok diambil dari kolom PriceColumn lalu dipotong DiscountPercent persen.
+type CustomerGroup struct {
+	ID              string          `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	Code            string          `gorm:"size:50;not null;uniqueIndex"`
+	Name            string          `gorm:"size:100;not null"`
+	Description     string          `gorm:"size:255"`
+	PriceColumn     string          `gorm:"size:20;not null;default:'retail'"`
+	DiscountPercent decimal.Decimal `gorm:"type:decimal(5,2)"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       gorm.DeletedAt
+}
+
+func (c *CustomerGroup) BeforeCreate(db *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+
+	if c.Code == "" {
+		c.Code = slug.Make(c.Name)
+	}
+
+	return nil
+}
+
+// Validate memeriksa isian kelompok pelanggan sebelum disimpan
+func (c *CustomerGroup) Validate() error {
+	if c.Name == "" {
+		return errors.New("Nama kelompok wajib diisi")
+	}
+	if _, ok := CustomerGroupPriceColumns[c.PriceColumn]; !ok {
+		return errors.New("Kolom harga tidak dikenal")
+	}
+	if c.DiscountPercent.IsNegative() || c.DiscountPercent.GreaterThan(decimal.NewFromInt(100)) {
+		return errors.New("Potongan persen harus antara 0 dan 100")
+	}
+
+	return nil
+}
+
+// PriceColumnLabel mengembalikan label kolom harga kelompok
+func (c *CustomerGroup) PriceColumnLabel() string {
+	return CustomerGroupPriceColumns[c.PriceColumn]
+}
+
+// HasSpecialPrice menandakan harga kelompok berbeda dari harga eceran
+func (c *CustomerGroup) HasSpecialPrice() bool {
+	return c.PriceColumn != CustomerGroupPriceRetail || c.DiscountPercent.IsPositive()
+}
+
+// Price menghitung harga per satuan untuk kelompok ini dari harga eceran dan harga grosir satuan.
+// Satuan yang belum memiliki harga grosir memakai harga eceran.
+func (c *CustomerGroup) Price(retailPrice decimal.Decimal, wholesalePrice decimal.Decimal) decimal.Decimal {
+	price := retailPrice
+	if c.PriceColumn == CustomerGroupPriceWholesale && !wholesalePrice.IsZero() {
+		price = wholesalePrice
+	}
+
+	hundred := decimal.NewFromInt(100)
+
+	return price.Mul(hundred.Sub(c.DiscountPercent)).Div(hundred).Round(0)
+}
+
+// GetCustomerGroups mengambil semua kelompok pelanggan, urut berdasarkan nama
+func (c *CustomerGroup) GetCustomerGroups(db *gorm.DB) ([]CustomerGroup, error) {
+	var groups []CustomerGroup
+
+	err := db.Debug().Model(&CustomerGroup{}).Order("name asc").Find(&groups).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func (c *CustomerGroup) FindByID(db *gorm.DB, groupID string) (*CustomerGroup, error) {
+	var group CustomerGroup
+
+	err := db.Debug().Model(&CustomerGroup{}).Where("id = ?", groupID).First(&group).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
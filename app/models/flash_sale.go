@@ -0,0 +1,251 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/gieart87/gotoko/app/consts"
+	"github.com/gieart87/gotoko/app/core/money"
+)
+
+// FlashSale adalah promo berjangka yang mengganti harga satu satuan produk selama periode
+// StartsAt sampai EndsAt. Quota adalah jumlah satuan yang boleh terjual dengan harga promo,
+// nol berarti tanpa batas.
+type FlashSale struct {
+	ID        string      `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	Name      string      `gorm:"size:100;not null"`
+	ProductID string      `gorm:"size:36;not null;index"`
+	UnitName  string      `gorm:"size:50;not null"` // Nama satuan, misalnya Dus
+	Price     money.Money `gorm:"type:decimal(16,2)"`
+	Quota     int
+	StartsAt  time.Time
+	EndsAt    time.Time
+	Status    int `gorm:"default:1"` // 1 aktif, 0 nonaktif
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt
+	Sold      int `gorm:"-"` // Satuan yang sudah terjual pada order yang tidak dibatalkan
+}
+
+func (f *FlashSale) BeforeCreate(db *gorm.DB) error {
+	if f.ID == "" {
+		f.ID = uuid.New().String()
+	}
+
+	return nil
+}
+
+// Validate memeriksa isian flash sale sebelum disimpan
+func (f *FlashSale) Validate() error {
+	if f.Name == "" {
+		return errors.New("Nama flash sale wajib diisi")
+	}
+	if f.ProductID == "" || f.UnitName == "" {
+		return errors.New("Produk dan satuan wajib diisi")
+	}
+	if !f.Price.IsPositive() {
+		return errors.New("Harga flash sale harus lebih dari 0")
+	}
+	if f.Quota < 0 {
+		return errors.New("Kuota tidak boleh negatif")
+	}
+	if f.StartsAt.IsZero() || f.EndsAt.IsZero() {
+		return errors.New("Waktu mulai dan selesai wajib diisi")
+	}
+	if !f.EndsAt.After(f.StartsAt) {
+		return errors.New("Waktu selesai harus setelah waktu mulai")
+	}
+
+	return nil
+}
+
+// IsActiveAt menandakan flash sale aktif dan sedang berlangsung pada waktu at
+func (f *FlashSale) IsActiveAt(at time.Time) bool {
+	return f.Status == 1 && !at.Before(f.StartsAt) && at.Before(f.EndsAt)
+}
+
+// Remaining mengembalikan sisa kuota dalam satuan flash sale. Hanya berarti jika Quota lebih dari nol.
+func (f *FlashSale) Remaining() int {
+	if f.Sold >= f.Quota {
+		return 0
+	}
+
+	return f.Quota - f.Sold
+}
+
+// Allows menandakan qty satuan masih bisa dibeli dengan harga flash sale
+func (f *FlashSale) Allows(qty int) bool {
+	return f.Quota == 0 || qty <= f.Remaining()
+}
+
+// GetFlashSales mengambil semua flash sale untuk halaman admin beserta jumlah terjualnya
+func (f *FlashSale) GetFlashSales(db *gorm.DB) ([]FlashSale, error) {
+	var sales []FlashSale
+
+	err := db.Debug().Model(&FlashSale{}).Order("starts_at desc").Find(&sales).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sales, loadFlashSaleSold(db, sales)
+}
+
+// GetActiveFlashSales mengambil flash sale yang berlangsung pada waktu at beserta jumlah terjualnya
+func (f *FlashSale) GetActiveFlashSales(db *gorm.DB, at time.Time) ([]FlashSale, error) {
+	var sales []FlashSale
+
+	err := db.Debug().Model(&FlashSale{}).Where("status = ?", 1).Find(&sales).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Periode berlaku dicek di Go agar tidak bergantung pada format waktu tiap driver database
+	active := sales[:0]
+	for _, sale := range sales {
+		if sale.IsActiveAt(at) {
+			active = append(active, sale)
+		}
+	}
+
+	return active, loadFlashSaleSold(db, active)
+}
+
+func (f *FlashSale) FindByID(db *gorm.DB, saleID string) (*FlashSale, error) {
+	var sale FlashSale
+
+	err := db.Debug().Model(&FlashSale{}).Where("id = ?", saleID).First(&sale).Error
+	if err != nil {
+		return nil, err
+	}
+
+	sales := []FlashSale{sale}
+	if err := loadFlashSaleSold(db, sales); err != nil {
+		return nil, err
+	}
+
+	return &sales[0], nil
+}
+
+// FindByIDForUpdate mencari flash sale beserta jumlah terjualnya dan mengunci barisnya sampai transaksi database selesai
+func (f *FlashSale) FindByIDForUpdate(tx *gorm.DB, saleID string) (*FlashSale, error) {
+	var sale FlashSale
+
+	err := tx.Debug().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Model(&FlashSale{}).Where("id = ?", saleID).
+		First(&sale).Error
+	if err != nil {
+		return nil, err
+	}
+
+	sales := []FlashSale{sale}
+	if err := loadFlashSaleSold(tx, sales); err != nil {
+		return nil, err
+	}
+
+	return &sales[0], nil
+}
+
+// loadFlashSaleSold mengisi Sold setiap flash sale dari item order yang tidak dibatalkan
+func loadFlashSaleSold(db *gorm.DB, sales []FlashSale) error {
+	if len(sales) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(sales))
+	for i, sale := range sales {
+		ids[i] = sale.ID
+	}
+
+	var rows []struct {
+		FlashSaleID string
+		Sold        int
+	}
+	err := db.Debug().Model(&OrderItem{}).
+		Select("order_items.flash_sale_id, SUM(order_items.qty) AS sold").
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("order_items.flash_sale_id IN ? AND orders.status <> ?", ids, consts.OrderStatusCancelled).
+		Group("order_items.flash_sale_id").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	sold := map[string]int{}
+	for _, row := range rows {
+		sold[row.FlashSaleID] = row.Sold
+	}
+	for i := range sales {
+		sales[i].Sold = sold[sales[i].ID]
+	}
+
+	return nil
+}
+
+// FlashSaleError dikembalikan jika harga flash sale pada item keranjang tidak bisa dipakai lagi
+type FlashSaleError struct {
+	Message string
+}
+
+func (e *FlashSaleError) Error() string {
+	return e.Message
+}
+
+// CheckFlashSales memastikan item yang memakai harga flash sale masih dalam periode promo dan
+// kuotanya cukup. Dipanggil saat checkout karena promo bisa berakhir setelah item masuk keranjang.
+func CheckFlashSales(db *gorm.DB, items []CartItem) error {
+	return checkFlashSales(db, items, false)
+}
+
+// CheckFlashSalesForUpdate sama dengan CheckFlashSales tetapi mengunci baris flash sale. Harus dipanggil di dalam
+// transaksi pembuatan order sebelum item order disimpan, agar dua pembeli tidak sama-sama mengambil sisa kuota terakhir.
+func CheckFlashSalesForUpdate(tx *gorm.DB, items []CartItem) error {
+	return checkFlashSales(tx, items, true)
+}
+
+func checkFlashSales(db *gorm.DB, items []CartItem, lock bool) error {
+	qty := map[string]int{}
+	var saleIDs []string
+	for _, item := range items {
+		if item.FlashSaleID == "" {
+			continue
+		}
+		if _, ok := qty[item.FlashSaleID]; !ok {
+			saleIDs = append(saleIDs, item.FlashSaleID)
+		}
+		qty[item.FlashSaleID] += item.Qty
+	}
+	// Urutan penguncian dibuat tetap agar checkout bersamaan tidak saling menunggu
+	sort.Strings(saleIDs)
+
+	var saleModel FlashSale
+	for _, saleID := range saleIDs {
+		var sale *FlashSale
+		var err error
+		if lock {
+			sale, err = saleModel.FindByIDForUpdate(db, saleID)
+		} else {
+			sale, err = saleModel.FindByID(db, saleID)
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &FlashSaleError{Message: "Flash sale sudah tidak tersedia, harga keranjang telah diperbarui"}
+		}
+		if err != nil {
+			return err
+		}
+
+		if !sale.IsActiveAt(time.Now()) {
+			return &FlashSaleError{Message: fmt.Sprintf("Flash sale %s sudah berakhir, harga keranjang telah diperbarui", sale.Name)}
+		}
+		if !sale.Allows(qty[saleID]) {
+			return &FlashSaleError{Message: fmt.Sprintf("Sisa kuota flash sale %s tinggal %d %s, harga keranjang telah diperbarui", sale.Name, sale.Remaining(), sale.UnitName)}
+		}
+	}
+
+	return nil
+}
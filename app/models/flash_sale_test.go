@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/gieart87/gotoko/app/consts"
+	"github.com/gieart87/gotoko/app/core/money"
+)
+
+func TestCheckFlashSalesForUpdate(t *testing.T) {
+	db := newTestDB(t)
+
+	sale := FlashSale{Name: "Promo", ProductID: "P1", UnitName: "Dus", Price: money.FromInt(1000), Quota: 5,
+		StartsAt: time.Now().Add(-time.Hour), EndsAt: time.Now().Add(time.Hour), Status: 1}
+	if err := db.Create(&sale).Error; err != nil {
+		t.Fatalf("create flash sale: %v", err)
+	}
+
+	sell := func(orderID string, qty int, status int) {
+		t.Helper()
+		order := Order{ID: orderID, Code: orderID, Status: status, OrderItems: []OrderItem{{ProductID: "P1", Qty: qty, FlashSaleID: sale.ID}}}
+		if err := db.Create(&order).Error; err != nil {
+			t.Fatalf("create order: %v", err)
+		}
+	}
+	sell("O1", 3, consts.OrderStatusReceived)
+	sell("O2", 2, consts.OrderStatusCancelled) // Tidak mengurangi kuota
+
+	tests := []struct {
+		name    string
+		qty     int
+		wantErr bool
+	}{
+		{"sisa kuota cukup", 2, false},
+		{"melebihi sisa kuota", 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := []CartItem{{ProductID: "P1", Qty: tt.qty, FlashSaleID: sale.ID}}
+
+			err := db.Transaction(func(tx *gorm.DB) error {
+				return CheckFlashSalesForUpdate(tx, items)
+			})
+
+			var flashSaleErr *FlashSaleError
+			if tt.wantErr != errors.As(err, &flashSaleErr) {
+				t.Errorf("CheckFlashSalesForUpdate(qty %d) = %v, wantErr %v", tt.qty, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -11,8 +11,11 @@ import (
 	"github.com/google/uuid"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/shopspring/decimal"
+
+	"github.com/gieart87/gotoko/app/core/money"
 )
 
 type Order struct {
@@ -27,13 +30,14 @@ type Order struct {
 	PaymentDue          time.Time
 	PaymentStatus       string          `gorm:"size:50;index"`
 	PaymentToken        sql.NullString  `gorm:"size:100;index"`
-	BaseTotalPrice      decimal.Decimal `gorm:"type:decimal(16,2)"`
-	TaxAmount           decimal.Decimal `gorm:"type:decimal(16,2)"`
+	BaseTotalPrice      money.Money     `gorm:"type:decimal(16,2)"`
+	TaxAmount           money.Money     `gorm:"type:decimal(16,2)"`
 	TaxPercent          decimal.Decimal `gorm:"type:decimal(10,2)"`
-	DiscountAmount      decimal.Decimal `gorm:"type:decimal(16,2)"`
+	DiscountAmount      money.Money     `gorm:"type:decimal(16,2)"`
 	DiscountPercent     decimal.Decimal `gorm:"type:decimal(10,2)"`
-	ShippingCost        decimal.Decimal `gorm:"type:decimal(16,2)"`
-	GrandTotal          decimal.Decimal `gorm:"type:decimal(16,2)"`
+	ShippingCost        money.Money     `gorm:"type:decimal(16,2)"`
+	GrandTotal          money.Money     `gorm:"type:decimal(16,2)"`
+	VoucherCode         string          `gorm:"size:50"` // Voucher yang dipakai saat checkout
 	Note                string          `gorm:"type:text"`
 	ShippingCourier     string          `gorm:"size:100"`
 	ShippingServiceName string          `gorm:"size:100"`
@@ -66,6 +70,13 @@ func (o *Order) CreateOrder(db *gorm.DB, order *Order) (*Order, error) {
 	return order, nil
 }
 
+// SetPaymentToken menyimpan URL pembayaran dari payment gateway
+func (o *Order) SetPaymentToken(db *gorm.DB, token string) error {
+	o.PaymentToken = sql.NullString{String: token, Valid: true}
+
+	return db.Debug().Model(&Order{}).Where("id = ?", o.ID).Update("payment_token", o.PaymentToken).Error
+}
+
 func (o *Order) FindByID(db *gorm.DB, id string) (*Order, error) {
 	var order Order
 
@@ -84,6 +95,36 @@ func (o *Order) FindByID(db *gorm.DB, id string) (*Order, error) {
 	return &order, nil
 }
 
+// GetAwaitingPaymentOrders mengambil order yang masih menunggu pembayaran dan sudah memiliki payment token
+func (o *Order) GetAwaitingPaymentOrders(db *gorm.DB) ([]Order, error) {
+	var orders []Order
+
+	err := db.Debug().Model(&Order{}).
+		Where("payment_status IN ?", []string{consts.OrderPaymentStatusUnpaid, consts.OrderPaymentStatusFailed}).
+		Where("payment_token IS NOT NULL AND payment_token <> ''").
+		Order("created_at asc").
+		Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// FindByIDForUpdate mencari order tanpa relasi dan mengunci barisnya sampai transaksi database selesai
+func (o *Order) FindByIDForUpdate(tx *gorm.DB, id string) (*Order, error) {
+	var order Order
+
+	err := tx.Debug().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Model(&Order{}).Where("id = ?", id).
+		First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
 func (o *Order) GetStatusLabel() string {
 	var statusLabel string
 
@@ -161,14 +202,117 @@ func intToRoman(num int) string {
 	return roman
 }
 
+// MarkAsPaid menandai order dibayar dan mengurangi stok produk yang ditahan untuk order ini
 func (o *Order) MarkAsPaid(db *gorm.DB) error {
 	o.PaymentStatus = consts.OrderPaymentStatusPaid
-	o.Status = 1
+	o.Status = consts.OrderStatusReceived
+
+	err := db.Save(o).Error
+	if err != nil {
+		return err
+	}
+
+	return CommitStockReservations(db, o)
+}
+
+// MarkAsPaymentFailed menandai pembayaran ditolak. Order tetap PENDING agar pelanggan
+// masih dapat membayar ulang dengan metode lain sebelum transaksi kedaluwarsa.
+func (o *Order) MarkAsPaymentFailed(db *gorm.DB) error {
+	o.PaymentStatus = consts.OrderPaymentStatusFailed
+
+	return db.Save(o).Error
+}
+
+// MarkAsCancelled membatalkan order karena pembayaran dibatalkan atau kedaluwarsa, stok yang ditahan dilepas
+func (o *Order) MarkAsCancelled(db *gorm.DB, paymentStatus string, note string) error {
+	o.PaymentStatus = paymentStatus
+	o.Status = consts.OrderStatusCancelled
+	o.CancelledAt = sql.NullTime{Time: time.Now(), Valid: true}
+	o.CancellationNote = sql.NullString{String: note, Valid: true}
 
 	err := db.Save(o).Error
 	if err != nil {
 		return err
 	}
 
+	return ReleaseStockReservations(db, o.ID, note)
+}
+
+// MarkAsRefundDue menandai order yang dibayar setelah dibatalkan, misalnya pembayaran yang masuk setelah
+// reservasinya kedaluwarsa. Order tetap dibatalkan dan stoknya tidak dikurangi, dananya harus dikembalikan.
+func (o *Order) MarkAsRefundDue(db *gorm.DB) error {
+	o.PaymentStatus = consts.OrderPaymentStatusRefundDue
+
+	return db.Save(o).Error
+}
+
+// MarkAsRefunded menandai dana order telah dikembalikan sebagian atau seluruhnya
+func (o *Order) MarkAsRefunded(db *gorm.DB, partial bool) error {
+	o.PaymentStatus = consts.OrderPaymentStatusRefunded
+	if partial {
+		o.PaymentStatus = consts.OrderPaymentStatusPartialRefund
+	}
+
+	return db.Save(o).Error
+}
+
+// ApplyPaymentNotification menerapkan status transaksi Midtrans ke PaymentStatus dan Status order.
+// Status yang tidak mengubah order (pending, capture dengan fraud challenge) atau yang tidak
+// berlaku untuk status order saat ini diabaikan sehingga notifikasi yang terlambat tidak
+// memundurkan order yang sudah dibayar. Pembayaran untuk order yang sudah dibatalkan dicatat
+// sebagai REFUND_DUE agar dananya dikembalikan.
+func (o *Order) ApplyPaymentNotification(db *gorm.DB, notification *MidtransNotification) error {
+	switch notification.TransactionStatus {
+	case consts.PaymentStatusCapture:
+		if notification.FraudStatus == consts.FraudStatusAccept {
+			return o.applyPayment(db)
+		}
+	case consts.PaymentStatusSettlement:
+		return o.applyPayment(db)
+	case consts.PaymentStatusDeny:
+		if o.PaymentStatus == consts.OrderPaymentStatusUnpaid {
+			return o.MarkAsPaymentFailed(db)
+		}
+	case consts.PaymentStatusCancel:
+		if o.IsAwaitingPayment() {
+			return o.MarkAsCancelled(db, consts.OrderPaymentStatusCancelled, "Pembayaran dibatalkan")
+		}
+	case consts.PaymentStatusExpire:
+		if o.IsAwaitingPayment() {
+			return o.MarkAsCancelled(db, consts.OrderPaymentStatusExpired, "Pembayaran kedaluwarsa")
+		}
+	case consts.PaymentStatusRefund:
+		if o.IsPaid() || o.PaymentStatus == consts.OrderPaymentStatusPartialRefund || o.IsRefundDue() {
+			return o.MarkAsRefunded(db, false)
+		}
+	case consts.PaymentStatusPartialRefund:
+		if o.IsPaid() || o.IsRefundDue() {
+			return o.MarkAsRefunded(db, true)
+		}
+	}
+
 	return nil
 }
+
+// applyPayment menerapkan pembayaran yang berhasil: order yang menunggu pembayaran ditandai dibayar,
+// order yang sudah dibatalkan ditandai harus dikembalikan dananya
+func (o *Order) applyPayment(db *gorm.DB) error {
+	if o.IsAwaitingPayment() {
+		return o.MarkAsPaid(db)
+	}
+	if o.PaymentStatus == consts.OrderPaymentStatusCancelled || o.PaymentStatus == consts.OrderPaymentStatusExpired {
+		return o.MarkAsRefundDue(db)
+	}
+
+	return nil
+}
+
+// IsRefundDue menandakan order dibayar setelah dibatalkan dan dananya belum dikembalikan
+func (o *Order) IsRefundDue() bool {
+	return o.PaymentStatus == consts.OrderPaymentStatusRefundDue
+}
+
+// IsAwaitingPayment menandakan order masih menunggu pembayaran (belum dibayar atau pernah ditolak)
+func (o *Order) IsAwaitingPayment() bool {
+	return o.PaymentStatus == consts.OrderPaymentStatusUnpaid || o.PaymentStatus == consts.OrderPaymentStatusFailed
+}
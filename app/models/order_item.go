@@ -7,6 +7,8 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/shopspring/decimal"
+
+	"github.com/gieart87/gotoko/app/core/money"
 )
 
 type OrderItem struct {
@@ -17,14 +19,18 @@ type OrderItem struct {
 	ProductID       string `gorm:"size:36;index"`
 	Qty             int
 	Unit            string          `gorm:"size:50"` // Field untuk menyimpan satuan
-	Pricenew        int
-	BasePrice       decimal.Decimal `gorm:"type:decimal(16,2)"`
-	BaseTotal       decimal.Decimal `gorm:"type:decimal(16,2)"`
-	TaxAmount       decimal.Decimal `gorm:"type:decimal(16,2)"`
+	ProductUnitID   string          `gorm:"size:36;index"`
+	PriceTier       string          `gorm:"size:100"`           // Label tingkat harga yang dipakai, misalnya Eceran
+	FlashSaleID     string          `gorm:"size:36;index"`      // Flash sale yang harganya dipakai, dihitung ke kuotanya
+	Pricenew        money.Money     `gorm:"type:decimal(16,2)"` // Harga jual satuan yang dipakai
+	BasePrice       money.Money     `gorm:"type:decimal(16,2)"`
+	BaseTotal       money.Money     `gorm:"type:decimal(16,2)"`
+	TaxAmount       money.Money     `gorm:"type:decimal(16,2)"`
 	TaxPercent      decimal.Decimal `gorm:"type:decimal(10,2)"`
-	DiscountAmount  decimal.Decimal `gorm:"type:decimal(16,2)"`
+	DiscountAmount  money.Money     `gorm:"type:decimal(16,2)"`
 	DiscountPercent decimal.Decimal `gorm:"type:decimal(10,2)"`
-	SubTotal        decimal.Decimal `gorm:"type:decimal(16,2)"`
+	SubTotal        money.Money     `gorm:"type:decimal(16,2)"`
+	CostPrice       money.Money     `gorm:"type:decimal(16,2)"` // Harga pokok satuan saat order dibuat
 	Sku             string          `gorm:"size:36;index"`
 	Name            string          `gorm:"size:255"`
 	Weight          decimal.Decimal `gorm:"type:decimal(10,2)"`
@@ -39,3 +45,8 @@ func (o *OrderItem) BeforeCreate(db *gorm.DB) error {
 
 	return nil
 }
+
+// CostTotal adalah harga pokok penjualan (HPP) item
+func (o *OrderItem) CostTotal() money.Money {
+	return o.CostPrice.Times(o.Qty)
+}
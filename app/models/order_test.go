@@ -0,0 +1,128 @@
+package models
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/glebarez/go-sqlite"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/gieart87/gotoko/app/consts"
+	"github.com/gieart87/gotoko/database/migrations"
+)
+
+// newTestDB membuat database SQLite sementara dengan skema dari migrasi
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	db, err := gorm.Open(sqlite.Dialector{DriverName: "sqlite", DSN: dsn}, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+
+	err = migrations.Migrate(db)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	return db
+}
+
+func TestOrderApplyPaymentNotification(t *testing.T) {
+	tests := []struct {
+		name          string
+		paymentStatus string
+		status        int
+		transaction   string
+		fraud         string
+		wantPayment   string
+		wantStatus    int
+		wantStock     int
+		wantHold      string
+	}{
+		{"settlement membayar order", consts.OrderPaymentStatusUnpaid, consts.OrderStatusPending, consts.PaymentStatusSettlement, "",
+			consts.OrderPaymentStatusPaid, consts.OrderStatusReceived, 7, StockReservationCommitted},
+		{"capture diterima membayar order", consts.OrderPaymentStatusUnpaid, consts.OrderStatusPending, consts.PaymentStatusCapture, consts.FraudStatusAccept,
+			consts.OrderPaymentStatusPaid, consts.OrderStatusReceived, 7, StockReservationCommitted},
+		{"capture challenge diabaikan", consts.OrderPaymentStatusUnpaid, consts.OrderStatusPending, consts.PaymentStatusCapture, consts.FraudStatusChallenge,
+			consts.OrderPaymentStatusUnpaid, consts.OrderStatusPending, 10, StockReservationHeld},
+		{"pending diabaikan", consts.OrderPaymentStatusUnpaid, consts.OrderStatusPending, consts.PaymentStatusPending, "",
+			consts.OrderPaymentStatusUnpaid, consts.OrderStatusPending, 10, StockReservationHeld},
+		{"deny menandai pembayaran gagal", consts.OrderPaymentStatusUnpaid, consts.OrderStatusPending, consts.PaymentStatusDeny, "",
+			consts.OrderPaymentStatusFailed, consts.OrderStatusPending, 10, StockReservationHeld},
+		{"settlement setelah gagal membayar order", consts.OrderPaymentStatusFailed, consts.OrderStatusPending, consts.PaymentStatusSettlement, "",
+			consts.OrderPaymentStatusPaid, consts.OrderStatusReceived, 7, StockReservationCommitted},
+		{"cancel membatalkan order", consts.OrderPaymentStatusUnpaid, consts.OrderStatusPending, consts.PaymentStatusCancel, "",
+			consts.OrderPaymentStatusCancelled, consts.OrderStatusCancelled, 10, StockReservationReleased},
+		{"expire membatalkan order", consts.OrderPaymentStatusFailed, consts.OrderStatusPending, consts.PaymentStatusExpire, "",
+			consts.OrderPaymentStatusExpired, consts.OrderStatusCancelled, 10, StockReservationReleased},
+		{"cancel terlambat tidak memundurkan order dibayar", consts.OrderPaymentStatusPaid, consts.OrderStatusReceived, consts.PaymentStatusCancel, "",
+			consts.OrderPaymentStatusPaid, consts.OrderStatusReceived, 10, StockReservationHeld},
+		{"deny terlambat tidak memundurkan order dibayar", consts.OrderPaymentStatusPaid, consts.OrderStatusReceived, consts.PaymentStatusDeny, "",
+			consts.OrderPaymentStatusPaid, consts.OrderStatusReceived, 10, StockReservationHeld},
+		{"refund penuh", consts.OrderPaymentStatusPaid, consts.OrderStatusDelivered, consts.PaymentStatusRefund, "",
+			consts.OrderPaymentStatusRefunded, consts.OrderStatusDelivered, 10, StockReservationHeld},
+		{"refund sebagian", consts.OrderPaymentStatusPaid, consts.OrderStatusReceived, consts.PaymentStatusPartialRefund, "",
+			consts.OrderPaymentStatusPartialRefund, consts.OrderStatusReceived, 10, StockReservationHeld},
+		{"refund penuh setelah refund sebagian", consts.OrderPaymentStatusPartialRefund, consts.OrderStatusReceived, consts.PaymentStatusRefund, "",
+			consts.OrderPaymentStatusRefunded, consts.OrderStatusReceived, 10, StockReservationHeld},
+		{"settlement setelah kedaluwarsa menunggu refund", consts.OrderPaymentStatusExpired, consts.OrderStatusCancelled, consts.PaymentStatusSettlement, "",
+			consts.OrderPaymentStatusRefundDue, consts.OrderStatusCancelled, 10, StockReservationHeld},
+		{"capture setelah dibatalkan menunggu refund", consts.OrderPaymentStatusCancelled, consts.OrderStatusCancelled, consts.PaymentStatusCapture, consts.FraudStatusAccept,
+			consts.OrderPaymentStatusRefundDue, consts.OrderStatusCancelled, 10, StockReservationHeld},
+		{"refund untuk pembayaran setelah dibatalkan", consts.OrderPaymentStatusRefundDue, consts.OrderStatusCancelled, consts.PaymentStatusRefund, "",
+			consts.OrderPaymentStatusRefunded, consts.OrderStatusCancelled, 10, StockReservationHeld},
+		{"settlement setelah refund tidak menunggu refund lagi", consts.OrderPaymentStatusRefunded, consts.OrderStatusCancelled, consts.PaymentStatusSettlement, "",
+			consts.OrderPaymentStatusRefunded, consts.OrderStatusCancelled, 10, StockReservationHeld},
+		{"refund untuk order belum dibayar diabaikan", consts.OrderPaymentStatusUnpaid, consts.OrderStatusPending, consts.PaymentStatusRefund, "",
+			consts.OrderPaymentStatusUnpaid, consts.OrderStatusPending, 10, StockReservationHeld},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+
+			product := Product{ID: "P1", Name: "Produk", Slug: "produk", Stock: 10, Status: 1}
+			order := Order{ID: "O1", Code: "INV/1", PaymentStatus: tt.paymentStatus, Status: tt.status}
+			reservation := StockReservation{OrderID: order.ID, ProductID: product.ID, Qty: 3, Status: StockReservationHeld, ExpiresAt: time.Now().Add(time.Hour)}
+			for _, record := range []interface{}{&product, &order, &reservation} {
+				if err := db.Create(record).Error; err != nil {
+					t.Fatalf("create %T: %v", record, err)
+				}
+			}
+
+			err := db.Transaction(func(tx *gorm.DB) error {
+				return order.ApplyPaymentNotification(tx, &MidtransNotification{
+					OrderID:           order.ID,
+					TransactionStatus: tt.transaction,
+					FraudStatus:       tt.fraud,
+				})
+			})
+			if err != nil {
+				t.Fatalf("ApplyPaymentNotification: %v", err)
+			}
+
+			var saved Order
+			db.First(&saved, "id = ?", order.ID)
+			if saved.PaymentStatus != tt.wantPayment || saved.Status != tt.wantStatus {
+				t.Errorf("order = %s/%d, want %s/%d", saved.PaymentStatus, saved.Status, tt.wantPayment, tt.wantStatus)
+			}
+
+			var stock Product
+			db.First(&stock, "id = ?", product.ID)
+			if stock.Stock != tt.wantStock {
+				t.Errorf("stok = %d, want %d", stock.Stock, tt.wantStock)
+			}
+
+			var hold StockReservation
+			db.First(&hold, "id = ?", reservation.ID)
+			if hold.Status != tt.wantHold {
+				t.Errorf("reservasi = %s, want %s", hold.Status, tt.wantHold)
+			}
+		})
+	}
+}
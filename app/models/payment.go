@@ -8,8 +8,9 @@ import (
 
 	"github.com/google/uuid"
 
-	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+
+	"github.com/gieart87/gotoko/app/core/money"
 )
 
 type Payment struct {
@@ -17,9 +18,9 @@ type Payment struct {
 	Order             Order
 	OrderID           string           `gorm:"size:36;index"`
 	Number            string           `gorm:"size:100;index"`
-	Amount            decimal.Decimal  `gorm:"type:decimal(16,2)"`
-	TransactionID     string           `gorm:"size:100;index"`
-	TransactionStatus string           `gorm:"size:100;index"`
+	Amount            money.Money      `gorm:"type:decimal(16,2)"`
+	TransactionID     string           `gorm:"size:100;index;index:idx_payment_transaction,priority:1"`
+	TransactionStatus string           `gorm:"size:100;index;index:idx_payment_transaction,priority:2"`
 	Payload           *json.RawMessage `gorm:"type:json;not null;"`
 	PaymentType       string           `gorm:"size:100"`
 	CreatedAt         time.Time
@@ -111,3 +112,17 @@ func (p *Payment) CreatePayment(db *gorm.DB, payment *Payment) (*Payment, error)
 
 	return payment, nil
 }
+
+// FindByTransaction mencari pembayaran yang sudah tercatat untuk kombinasi transaction ID dan status
+func (p *Payment) FindByTransaction(db *gorm.DB, transactionID string, transactionStatus string) (*Payment, error) {
+	var payment Payment
+
+	err := db.Debug().Model(&Payment{}).
+		Where("transaction_id = ? AND transaction_status = ?", transactionID, transactionStatus).
+		First(&payment).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &payment, nil
+}
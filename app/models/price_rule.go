@@ -0,0 +1,168 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Jenis aturan harga
+const (
+	PriceRuleTypeWholesale = "wholesale" // Harga grosir satuan (WholesalePrice)
+	PriceRuleTypeFixed     = "fixed"     // Harga tetap per satuan sebesar Value
+	PriceRuleTypePercent   = "percent"   // Potongan Value persen dari harga eceran
+	PriceRuleTypeAmount    = "amount"    // Potongan Value rupiah dari harga eceran
+)
+
+// PriceRuleTypes adalah jenis aturan harga beserta labelnya, dipakai di form admin
+var PriceRuleTypes = map[string]string{
+	PriceRuleTypeWholesale: "Harga grosir",
+	PriceRuleTypeFixed:     "Harga tetap",
+	PriceRuleTypePercent:   "Potongan persen",
+	PriceRuleTypeAmount:    "Potongan rupiah",
+}
+
+// PriceRule adalah aturan harga bertingkat. Field pembatas yang kosong berarti berlaku untuk semua,
+// misalnya CategoryID kosong berarti berlaku di semua kategori.
+type PriceRule struct {
+	ID              string          `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	Name            string          `gorm:"size:100;not null"`
+	ProductID       string          `gorm:"size:36;index"`
+	UnitName        string          `gorm:"size:50"` // Nama satuan, misalnya Dus
+	CategoryID      string          `gorm:"size:36;index"`
+	CustomerGroupID string          `gorm:"size:36;index"`
+	MinQty          int             `gorm:"not null;default:1"`
+	Type            string          `gorm:"size:20;not null"`
+	Value           decimal.Decimal `gorm:"type:decimal(16,2)"`
+	StartsAt        sql.NullTime
+	EndsAt          sql.NullTime
+	Status          int `gorm:"default:1"` // 1 aktif, 0 nonaktif
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       gorm.DeletedAt
+}
+
+func (p *PriceRule) BeforeCreate(db *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+
+	return nil
+}
+
+// Validate memeriksa isian aturan harga sebelum disimpan
+func (p *PriceRule) Validate() error {
+	if p.Name == "" {
+		return errors.New("Nama aturan wajib diisi")
+	}
+	if _, ok := PriceRuleTypes[p.Type]; !ok {
+		return errors.New("Jenis aturan tidak dikenal")
+	}
+	if p.MinQty < 1 {
+		return errors.New("Qty minimum paling sedikit 1")
+	}
+	if p.Value.IsNegative() {
+		return errors.New("Nilai tidak boleh negatif")
+	}
+	if p.Type == PriceRuleTypePercent && p.Value.GreaterThan(decimal.NewFromInt(100)) {
+		return errors.New("Potongan persen tidak boleh lebih dari 100")
+	}
+	if p.Type == PriceRuleTypeFixed && (p.ProductID == "" || p.UnitName == "") {
+		return errors.New("Harga tetap harus untuk satu produk dan satuan tertentu")
+	}
+	if p.StartsAt.Valid && p.EndsAt.Valid && !p.EndsAt.Time.After(p.StartsAt.Time) {
+		return errors.New("Waktu selesai harus setelah waktu mulai")
+	}
+
+	return nil
+}
+
+// TypeLabel mengembalikan label jenis aturan
+func (p *PriceRule) TypeLabel() string {
+	return PriceRuleTypes[p.Type]
+}
+
+// IsActiveAt menandakan aturan aktif dan berada dalam periode berlakunya pada waktu at
+func (p *PriceRule) IsActiveAt(at time.Time) bool {
+	if p.Status != 1 {
+		return false
+	}
+	if p.StartsAt.Valid && at.Before(p.StartsAt.Time) {
+		return false
+	}
+	if p.EndsAt.Valid && !at.Before(p.EndsAt.Time) {
+		return false
+	}
+
+	return true
+}
+
+// Apply menghitung harga per satuan menurut aturan ini dari harga eceran dan harga grosir satuan
+func (p *PriceRule) Apply(retailPrice decimal.Decimal, wholesalePrice decimal.Decimal) decimal.Decimal {
+	var price decimal.Decimal
+	switch p.Type {
+	case PriceRuleTypeWholesale:
+		price = wholesalePrice
+	case PriceRuleTypeFixed:
+		price = p.Value
+	case PriceRuleTypePercent:
+		price = retailPrice.Mul(decimal.NewFromInt(100).Sub(p.Value)).Div(decimal.NewFromInt(100))
+	case PriceRuleTypeAmount:
+		price = retailPrice.Sub(p.Value)
+	default:
+		return retailPrice
+	}
+
+	if price.IsNegative() {
+		return decimal.Zero
+	}
+
+	return price.Round(0)
+}
+
+// GetPriceRules mengambil semua aturan harga untuk halaman admin
+func (p *PriceRule) GetPriceRules(db *gorm.DB) ([]PriceRule, error) {
+	var rules []PriceRule
+
+	err := db.Debug().Model(&PriceRule{}).Order("created_at desc").Find(&rules).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// GetActiveRules mengambil aturan harga yang aktif pada waktu at
+func (p *PriceRule) GetActiveRules(db *gorm.DB, at time.Time) ([]PriceRule, error) {
+	var rules []PriceRule
+
+	err := db.Debug().Model(&PriceRule{}).Where("status = ?", 1).Find(&rules).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Periode berlaku dicek di Go agar tidak bergantung pada format waktu tiap driver database
+	active := rules[:0]
+	for _, rule := range rules {
+		if rule.IsActiveAt(at) {
+			active = append(active, rule)
+		}
+	}
+
+	return active, nil
+}
+
+func (p *PriceRule) FindByID(db *gorm.DB, ruleID string) (*PriceRule, error) {
+	var rule PriceRule
+
+	err := db.Debug().Model(&PriceRule{}).Where("id = ?", ruleID).First(&rule).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
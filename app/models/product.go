@@ -1,6 +1,8 @@
 package models
 
 import (
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -11,25 +13,12 @@ type Product struct {
 	ID               string `gorm:"size:36;not null;uniqueIndex;primary_key"`
 	ParentID         string `gorm:"size:36;index"`
 	Name             string `gorm:"size:255"`
-	SATUAN1          string `gorm:"size:255"`
-	SATUAN2          string `gorm:"size:255"`
-	SATUAN3          string `gorm:"size:255"`
-	KONVERSI1        int
-	KONVERSI2        int
-	KONVERSI3        int
-	HARGAPOKOK1      decimal.Decimal `gorm:"type:decimal(16,2);"`
-	HARGAPOKOK2      decimal.Decimal `gorm:"type:decimal(16,2);"`
-	HARGAPOKOK3      decimal.Decimal `gorm:"type:decimal(16,2);"`
-	HJ1              decimal.Decimal `gorm:"type:decimal(16,2);"`
-	HJ2              decimal.Decimal `gorm:"type:decimal(16,2);"`
-	HJ3              decimal.Decimal `gorm:"type:decimal(16,2);"`
-	HJ2_1            decimal.Decimal `gorm:"type:decimal(16,2);"`
-	HJ2_2            decimal.Decimal `gorm:"type:decimal(16,2);"`
-	HJ2_3            decimal.Decimal `gorm:"type:decimal(16,2);"`
 	Stock            int
 	Supplier         string `gorm:"type:text"`
+	TaxClassID       string `gorm:"size:36;index"` // Kosong berarti dikenai PPN
 	ProductImages    []ProductImage
-	Categories       string          `gorm:"size:255"`
+	Units            []ProductUnit
+	Categories       []Category      `gorm:"many2many:product_categories;"`
 	Sku              string          `gorm:"size:100;index"`
 	Slug             string          `gorm:"size:255"`
 	Price            decimal.Decimal `gorm:"type:decimal(16,2);"`
@@ -40,6 +29,7 @@ type Product struct {
 	CreatedAt        time.Time
 	UpdatedAt        time.Time
 	DeletedAt        gorm.DeletedAt
+	Held             int `gorm:"-"` // Stok dalam satuan dasar yang ditahan order belum dibayar, lihat LoadHeldStock
 }
 
 func (p *Product) GetProducts(db *gorm.DB, perPage int, page int) (*[]Product, int64, error) {
@@ -54,7 +44,7 @@ func (p *Product) GetProducts(db *gorm.DB, perPage int, page int) (*[]Product, i
 
 	offset := (page - 1) * perPage
 
-	err = db.Debug().Preload("ProductImages").Model(&Product{}).Order("stock desc").Limit(perPage).Offset(offset).Find(&products).Error
+	err = db.Debug().Preload("ProductImages").Preload("Units", orderUnits).Model(&Product{}).Order("stock desc").Limit(perPage).Offset(offset).Find(&products).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -66,7 +56,7 @@ func (p *Product) FindBySlug(db *gorm.DB, slug string) (*Product, error) {
 	var err error
 	var product Product
 
-	err = db.Debug().Preload("ProductImages").Model(&Product{}).Where("slug = ?", slug).First(&product).Error
+	err = db.Debug().Preload("ProductImages").Preload("Units", orderUnits).Preload("Categories").Model(&Product{}).Where("slug = ?", slug).First(&product).Error
 	if err != nil {
 		return nil, err
 	}
@@ -78,7 +68,7 @@ func (p *Product) FindByID(db *gorm.DB, productID string) (*Product, error) {
 	var err error
 	var product Product
 
-	err = db.Debug().Preload("ProductImages").Model(&Product{}).Where("id = ?", productID).First(&product).Error
+	err = db.Debug().Preload("ProductImages").Preload("Units", orderUnits).Model(&Product{}).Where("id = ?", productID).First(&product).Error
 	if err != nil {
 		return nil, err
 	}
@@ -94,8 +84,10 @@ func (p *Product) SearchProducts(db *gorm.DB, query string, perPage int, page in
 	searchQuery := "%" + query + "%"
 
 	queryBuilder := db.Debug().Model(&Product{}).Where(
-		"LOWER(name) LIKE LOWER(?) OR LOWER(categories) LIKE LOWER(?) OR LOWER(satuan1) LIKE LOWER(?) OR LOWER(satuan2) LIKE LOWER(?) OR LOWER(satuan3) LIKE LOWER(?)",
-		searchQuery, searchQuery, searchQuery, searchQuery, searchQuery,
+		"LOWER(name) LIKE LOWER(?) OR id IN (SELECT product_id FROM product_units WHERE LOWER(product_units.name) LIKE LOWER(?)) OR id IN ("+
+			"SELECT product_categories.product_id FROM product_categories "+
+			"JOIN categories ON categories.id = product_categories.category_id WHERE LOWER(categories.name) LIKE LOWER(?))",
+		searchQuery, searchQuery, searchQuery,
 	)
 
 	err = queryBuilder.Count(&count).Error
@@ -105,10 +97,134 @@ func (p *Product) SearchProducts(db *gorm.DB, query string, perPage int, page in
 
 	offset := (page - 1) * perPage
 
-	err = queryBuilder.Preload("ProductImages").Order("stock desc").Limit(perPage).Offset(offset).Find(&products).Error
+	err = queryBuilder.Preload("ProductImages").Preload("Units", orderUnits).Preload("Categories").Order("stock desc").Limit(perPage).Offset(offset).Find(&products).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
 	return &products, count, nil
 }
+
+// CategoryNames mengembalikan nama kategori produk dipisahkan koma
+func (p *Product) CategoryNames() string {
+	names := make([]string, 0, len(p.Categories))
+	for _, category := range p.Categories {
+		names = append(names, category.Name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// FindUnit mencari satuan produk berdasarkan nama
+func (p *Product) FindUnit(name string) (*ProductUnit, bool) {
+	for i := range p.Units {
+		if p.Units[i].Name == name {
+			return &p.Units[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// BaseUnit mengembalikan satuan pertama (satuan dasar) produk
+func (p *Product) BaseUnit() *ProductUnit {
+	if len(p.Units) == 0 {
+		return nil
+	}
+
+	return &p.Units[0]
+}
+
+// ListPrice adalah harga yang ditampilkan di daftar produk, yaitu harga grosir satuan dasar
+func (p *Product) ListPrice() decimal.Decimal {
+	unit := p.BaseUnit()
+	if unit == nil {
+		return p.Price
+	}
+
+	return unit.WholesalePrice
+}
+
+// FindUnitForItem mencari satuan item keranjang/order berdasarkan ID satuan,
+// atau berdasarkan nama satuan untuk item lama yang belum menyimpan ID satuan
+func (p *Product) FindUnitForItem(unitID string, unitName string) (*ProductUnit, bool) {
+	if unitID != "" {
+		for i := range p.Units {
+			if p.Units[i].ID == unitID {
+				return &p.Units[i], true
+			}
+		}
+	}
+
+	return p.FindUnit(unitName)
+}
+
+// IsActive menandakan produk sedang dijual (Status 1)
+func (p *Product) IsActive() bool {
+	return p.Status == 1
+}
+
+// AvailableStock adalah stok dalam satuan dasar yang masih bisa dipesan, yaitu stok dikurangi stok yang ditahan
+func (p *Product) AvailableStock() int {
+	if p.Stock < p.Held {
+		return 0
+	}
+
+	return p.Stock - p.Held
+}
+
+// BaseUnitName mengembalikan nama satuan dasar, dipakai saat menampilkan stok
+func (p *Product) BaseUnitName() string {
+	unit := p.BaseUnit()
+	if unit == nil {
+		return ""
+	}
+
+	return unit.Name
+}
+
+// GetCategoryIDs mengembalikan ID kategori produk beserta semua kategori induknya
+func (p *Product) GetCategoryIDs(db *gorm.DB) ([]string, error) {
+	var categories []Category
+	err := db.Debug().Model(&Category{}).
+		Where("id IN (SELECT category_id FROM product_categories WHERE product_id = ?)", p.ID).
+		Find(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, category := range categories {
+		ids = append(ids, category.ID)
+
+		ancestors, err := category.GetAncestors(db)
+		if err != nil {
+			return nil, err
+		}
+		for _, ancestor := range ancestors {
+			ids = append(ids, ancestor.ID)
+		}
+	}
+
+	return ids, nil
+}
+
+// TaxRate mengembalikan tarif pajak produk dari kelas pajaknya. Produk tanpa kelas pajak,
+// atau dengan kelas pajak yang sudah dihapus, dikenai tarif PPN.
+func (p *Product) TaxRate(db *gorm.DB) (decimal.Decimal, error) {
+	setting := GetTaxSetting()
+	if p.TaxClassID == "" {
+		return setting.PPNRate, nil
+	}
+
+	var class TaxClass
+	err := db.Debug().Model(&TaxClass{}).Where("id = ?", p.TaxClassID).First(&class).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return setting.PPNRate, nil
+	}
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	return class.RateFor(setting), nil
+}
@@ -16,3 +16,25 @@ type ProductImage struct {
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 }
+
+// Thumbnail mengembalikan path varian gambar sesuai ukuran (xl, large, medium, small),
+// atau path gambar asli jika varian tersebut belum dibuat
+func (p ProductImage) Thumbnail(size string) string {
+	var path string
+	switch size {
+	case "xl":
+		path = p.ExtraLarge
+	case "large":
+		path = p.Large
+	case "medium":
+		path = p.Medium
+	case "small":
+		path = p.Small
+	}
+
+	if path == "" {
+		return p.Path
+	}
+
+	return path
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ProductUnit adalah satuan jual produk, misalnya pcs, pack, slop atau dus
+type ProductUnit struct {
+	ID             string          `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	ProductID      string          `gorm:"size:36;index;uniqueIndex:idx_product_unit_name,priority:1"`
+	Name           string          `gorm:"size:50;not null;uniqueIndex:idx_product_unit_name,priority:2"`
+	Conversion     int             `gorm:"not null;default:1"` // Jumlah satuan dasar dalam satu satuan ini
+	CostPrice      decimal.Decimal `gorm:"type:decimal(16,2)"`
+	RetailPrice    decimal.Decimal `gorm:"type:decimal(16,2)"`
+	WholesalePrice decimal.Decimal `gorm:"type:decimal(16,2)"`
+	Barcode        string          `gorm:"size:100;index"`
+	SortOrder      int             `gorm:"default:0"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (u *ProductUnit) BeforeCreate(db *gorm.DB) error {
+	if u.ID == "" {
+		u.ID = uuid.New().String()
+	}
+
+	return nil
+}
+
+// ToBaseQty mengubah jumlah dalam satuan ini menjadi jumlah satuan dasar
+func (u *ProductUnit) ToBaseQty(qty int) int {
+	return qty * u.conversion()
+}
+
+// AvailableQty mengembalikan jumlah satuan ini yang dapat dipenuhi dari stok dalam satuan dasar
+func (u *ProductUnit) AvailableQty(stock int) int {
+	if stock <= 0 {
+		return 0
+	}
+
+	return stock / u.conversion()
+}
+
+func (u *ProductUnit) conversion() int {
+	if u.Conversion <= 0 {
+		return 1
+	}
+
+	return u.Conversion
+}
+
+// orderUnits dipakai saat Preload("Units") agar satuan selalu urut dari yang terkecil
+func orderUnits(db *gorm.DB) *gorm.DB {
+	return db.Order("sort_order asc")
+}
@@ -7,10 +7,23 @@ import (
 )
 
 type Role struct {
-	ID          string `gorm:"size:36;not null;uniqueIndex;primary_key"`
-	Name        string `gorm:"size:100;not null;index"`
-	Description string `gorm:"size:255"`
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	DeletedAt   gorm.DeletedAt
+	ID              string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	Name            string `gorm:"size:100;not null;index"`
+	Description     string `gorm:"size:255"`
+	CustomerGroupID string `gorm:"size:36;index"` // Kelompok pelanggan bawaan untuk pengguna dengan role ini
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       gorm.DeletedAt
+}
+
+// GetRoles mengambil semua role, dipakai di halaman admin
+func (r *Role) GetRoles(db *gorm.DB) ([]Role, error) {
+	var roles []Role
+
+	err := db.Debug().Model(&Role{}).Order("name asc").Find(&roles).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
 }
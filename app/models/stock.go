@@ -0,0 +1,105 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InsufficientStockError dikembalikan jika jumlah yang dipesan melebihi stok produk.
+// Requested dan Available dalam satuan dasar produk.
+type InsufficientStockError struct {
+	ProductName string
+	BaseUnit    string
+	Requested   int
+	Available   int
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("Stok %s tidak mencukupi: dipesan %d %s, tersedia %d %s",
+		e.ProductName, e.Requested, e.BaseUnit, e.Available, e.BaseUnit)
+}
+
+// CheckStock memastikan total jumlah item per produk, setelah dikonversi ke satuan dasar,
+// tidak melebihi stok produk yang belum ditahan order lain. Item dengan produk yang sama dari
+// satuan berbeda dijumlahkan.
+func CheckStock(db *gorm.DB, items []CartItem) error {
+	_, _, err := checkStock(db, items, false)
+
+	return err
+}
+
+// checkStock menghitung jumlah item per produk dalam satuan dasar lalu membandingkannya dengan stok
+// tersedia. Jika lock true, baris produk dikunci sampai transaksi selesai agar dua checkout tidak
+// menahan stok yang sama.
+func checkStock(db *gorm.DB, items []CartItem, lock bool) ([]*Product, map[string]int, error) {
+	var productIDs []string
+	requested := map[string]int{}
+	for _, item := range items {
+		if _, ok := requested[item.ProductID]; !ok {
+			productIDs = append(productIDs, item.ProductID)
+			requested[item.ProductID] = 0
+		}
+	}
+
+	if len(productIDs) == 0 {
+		return nil, requested, nil
+	}
+
+	query := db.Debug()
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var products []Product
+	err := query.Preload("Units", orderUnits).Where("id IN ?", productIDs).Find(&products).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	productByID := map[string]*Product{}
+	for i := range products {
+		productByID[products[i].ID] = &products[i]
+	}
+
+	for _, item := range items {
+		product, ok := productByID[item.ProductID]
+		if !ok {
+			return nil, nil, fmt.Errorf("produk %s tidak ditemukan", item.ProductID)
+		}
+
+		unit, ok := product.FindUnitForItem(item.ProductUnitID, item.Unit)
+		if !ok {
+			return nil, nil, fmt.Errorf("satuan %s tidak tersedia untuk produk %s", item.Unit, product.Name)
+		}
+
+		requested[product.ID] += unit.ToBaseQty(item.Qty)
+	}
+
+	held, err := HeldStock(db, productIDs, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ordered := make([]*Product, len(productIDs))
+	for i, productID := range productIDs {
+		product := productByID[productID]
+		available := product.Stock - held[productID]
+		if available < 0 {
+			available = 0
+		}
+		if requested[productID] > available {
+			return nil, nil, &InsufficientStockError{
+				ProductName: product.Name,
+				BaseUnit:    product.BaseUnitName(),
+				Requested:   requested[productID],
+				Available:   available,
+			}
+		}
+		ordered[i] = product
+	}
+
+	return ordered, requested, nil
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Jenis mutasi stok
+const (
+	StockMovementSale = "sale" // Barang keluar karena order dibayar
+)
+
+// StockMovement mencatat perubahan stok produk. Qty dalam satuan dasar, negatif untuk barang keluar.
+type StockMovement struct {
+	ID            string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	ProductID     string `gorm:"size:36;not null;index"`
+	OrderID       string `gorm:"size:36;index"`
+	ReservationID string `gorm:"size:36;index"`
+	Qty           int
+	Type          string `gorm:"size:20;not null"`
+	Note          string `gorm:"size:255"`
+	CreatedAt     time.Time
+}
+
+func (m *StockMovement) BeforeCreate(db *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = uuid.New().String()
+	}
+
+	return nil
+}
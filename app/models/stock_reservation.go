@@ -0,0 +1,201 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/gieart87/gotoko/app/consts"
+)
+
+// Status reservasi stok
+const (
+	StockReservationHeld      = "held"      // Stok ditahan sampai order dibayar atau ExpiresAt
+	StockReservationCommitted = "committed" // Order dibayar, stok produk sudah dikurangi
+	StockReservationReleased  = "released"  // Order batal atau kedaluwarsa, stok kembali tersedia
+)
+
+// StockReservation menahan stok satu produk untuk order yang belum dibayar. Qty dalam satuan dasar produk.
+type StockReservation struct {
+	ID          string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	OrderID     string `gorm:"size:36;not null;index"`
+	ProductID   string `gorm:"size:36;not null;index"`
+	Qty         int
+	Status      string `gorm:"size:20;not null;index"`
+	ExpiresAt   time.Time
+	CommittedAt *time.Time
+	ReleasedAt  *time.Time
+	Note        string `gorm:"size:255"` // Alasan stok dilepas
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (s *StockReservation) BeforeCreate(db *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+
+	return nil
+}
+
+// HeldStock menjumlahkan stok per produk yang masih ditahan dan belum kedaluwarsa pada waktu at
+func HeldStock(db *gorm.DB, productIDs []string, at time.Time) (map[string]int, error) {
+	var rows []struct {
+		ProductID string
+		Qty       int
+	}
+
+	err := db.Debug().Model(&StockReservation{}).
+		Select("product_id, SUM(qty) AS qty").
+		Where("product_id IN ? AND status = ? AND expires_at > ?", productIDs, StockReservationHeld, at).
+		Group("product_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	held := map[string]int{}
+	for _, row := range rows {
+		held[row.ProductID] = row.Qty
+	}
+
+	return held, nil
+}
+
+// LoadHeldStock mengisi Held setiap produk dengan stok yang sedang ditahan
+func LoadHeldStock(db *gorm.DB, products ...*Product) error {
+	productIDs := make([]string, len(products))
+	for i, product := range products {
+		productIDs[i] = product.ID
+	}
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	held, err := HeldStock(db, productIDs, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		product.Held = held[product.ID]
+	}
+
+	return nil
+}
+
+// ReserveStock menahan stok item keranjang untuk order sampai expiresAt. Harus dipanggil di dalam
+// transaksi yang sama dengan pembuatan order; baris produk dikunci agar stok terakhir tidak
+// ditahan dua order sekaligus.
+func ReserveStock(tx *gorm.DB, orderID string, items []CartItem, expiresAt time.Time) ([]StockReservation, error) {
+	products, requested, err := checkStock(tx, items, true)
+	if err != nil {
+		return nil, err
+	}
+
+	reservations := make([]StockReservation, len(products))
+	for i, product := range products {
+		reservations[i] = StockReservation{
+			OrderID:   orderID,
+			ProductID: product.ID,
+			Qty:       requested[product.ID],
+			Status:    StockReservationHeld,
+			ExpiresAt: expiresAt,
+		}
+	}
+	if len(reservations) == 0 {
+		return reservations, nil
+	}
+
+	err = tx.Debug().Create(&reservations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+// CommitStockReservations mengubah stok yang ditahan order menjadi mutasi stok keluar dan
+// mengurangi stok produk. Dipanggil saat order dibayar.
+func CommitStockReservations(tx *gorm.DB, order *Order) error {
+	reservations, err := heldReservations(tx, order.ID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range reservations {
+		reservation := &reservations[i]
+
+		err = tx.Debug().Model(&Product{}).Where("id = ?", reservation.ProductID).
+			UpdateColumn("stock", gorm.Expr("stock - ?", reservation.Qty)).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Debug().Create(&StockMovement{
+			ProductID:     reservation.ProductID,
+			OrderID:       order.ID,
+			ReservationID: reservation.ID,
+			Qty:           -reservation.Qty,
+			Type:          StockMovementSale,
+			Note:          "Order " + order.Code,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		reservation.Status = StockReservationCommitted
+		reservation.CommittedAt = &now
+		err = tx.Debug().Save(reservation).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReleaseStockReservations melepas stok yang masih ditahan order agar bisa dibeli pelanggan lain
+func ReleaseStockReservations(tx *gorm.DB, orderID string, note string) error {
+	return tx.Debug().Model(&StockReservation{}).
+		Where("order_id = ? AND status = ?", orderID, StockReservationHeld).
+		Updates(map[string]interface{}{
+			"status":      StockReservationReleased,
+			"released_at": time.Now(),
+			"note":        note,
+		}).Error
+}
+
+// GetExpiredReservationOrderIDs mengambil ID order yang stoknya masih ditahan padahal reservasinya
+// sudah kedaluwarsa pada waktu at atau ordernya sudah dibatalkan
+func GetExpiredReservationOrderIDs(db *gorm.DB, at time.Time) ([]string, error) {
+	cancelled := db.Model(&Order{}).Select("id").Where("status = ?", consts.OrderStatusCancelled)
+
+	var orderIDs []string
+	err := db.Debug().Model(&StockReservation{}).
+		Where("status = ?", StockReservationHeld).
+		Where("expires_at <= ? OR order_id IN (?)", at, cancelled).
+		Distinct().Pluck("order_id", &orderIDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return orderIDs, nil
+}
+
+// heldReservations mengambil reservasi order yang masih ditahan dan mengunci barisnya
+func heldReservations(tx *gorm.DB, orderID string) ([]StockReservation, error) {
+	var reservations []StockReservation
+
+	err := tx.Debug().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ? AND status = ?", orderID, StockReservationHeld).
+		Find(&reservations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/gieart87/gotoko/app/core/money"
+)
+
+// Mode harga jual terhadap PPN
+const (
+	TaxModeExclusive = "exclusive" // Harga jual belum termasuk PPN, PPN ditambahkan di atas harga
+	TaxModeInclusive = "inclusive" // Harga jual sudah termasuk PPN, PPN dihitung dari dalam harga
+)
+
+// TaxSetting adalah konfigurasi pajak toko
+type TaxSetting struct {
+	PPNRate decimal.Decimal // Tarif PPN dalam persen, 0 selama toko belum PKP
+	Mode    string          // TaxModeExclusive atau TaxModeInclusive
+}
+
+// Inclusive menandakan harga jual sudah termasuk PPN
+func (s TaxSetting) Inclusive() bool {
+	return s.Mode == TaxModeInclusive
+}
+
+var taxSetting = TaxSetting{PPNRate: decimal.Zero, Mode: TaxModeExclusive}
+
+// SetTaxSetting mengganti konfigurasi pajak, dipanggil sekali saat aplikasi dimulai
+func SetTaxSetting(setting TaxSetting) {
+	taxSetting = setting
+}
+
+// GetTaxSetting mengembalikan konfigurasi pajak yang berlaku
+func GetTaxSetting() TaxSetting {
+	return taxSetting
+}
+
+// TaxLine adalah rincian harga dan pajak satu baris item
+type TaxLine struct {
+	BasePrice  money.Money // Harga satuan sebelum pajak dan potongan
+	BaseTotal  money.Money // Dasar pengenaan pajak (DPP) satu baris, setelah potongan
+	TaxPercent decimal.Decimal
+	TaxAmount  money.Money // Pajak satu baris, dibulatkan ke rupiah
+	SubTotal   money.Money // Total baris setelah potongan, termasuk pajak
+}
+
+// CalculateTaxLine menghitung DPP dan pajak untuk qty satuan dengan harga jual price, setelah dipotong
+// discount untuk seluruh baris. Pajak dihitung per baris, bukan per satuan, agar pembulatan tidak
+// berlipat dengan qty.
+func CalculateTaxLine(price money.Money, qty int, discount money.Money, rate decimal.Decimal, setting TaxSetting) TaxLine {
+	total := price.Times(qty).Sub(discount)
+	line := TaxLine{BasePrice: price, BaseTotal: total, TaxPercent: rate, TaxAmount: money.Zero, SubTotal: total}
+
+	if !rate.IsPositive() {
+		return line
+	}
+
+	if setting.Inclusive() {
+		line.TaxAmount = total.PercentOfGross(rate).Round()
+		line.BaseTotal = total.Sub(line.TaxAmount)
+		if qty > 0 {
+			gross := price.Times(qty)
+			line.BasePrice = gross.Sub(gross.PercentOfGross(rate).Round()).Per(qty)
+		}
+		return line
+	}
+
+	line.TaxAmount = total.Percent(rate).Round()
+	line.SubTotal = total.Add(line.TaxAmount)
+
+	return line
+}
+
+// productTaxLine menghitung rincian pajak item keranjang dengan tarif kelas pajak produknya
+func productTaxLine(db *gorm.DB, product *Product, price money.Money, qty int, discount money.Money) (TaxLine, error) {
+	rate, err := product.TaxRate(db)
+	if err != nil {
+		return TaxLine{}, err
+	}
+
+	return CalculateTaxLine(price, qty, discount, rate, taxSetting), nil
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gosimple/slug"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// TaxClass adalah kelas pajak produk, misalnya barang kena PPN atau barang kebutuhan pokok yang dibebaskan
+type TaxClass struct {
+	ID        string              `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	Code      string              `gorm:"size:50;not null;uniqueIndex"`
+	Name      string              `gorm:"size:100;not null"`
+	Rate      decimal.NullDecimal `gorm:"type:decimal(5,2)"` // Tarif dalam persen, kosong berarti mengikuti tarif PPN
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt
+}
+
+func (t *TaxClass) BeforeCreate(db *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+
+	if t.Code == "" {
+		t.Code = slug.Make(t.Name)
+	}
+
+	return nil
+}
+
+// RateFor mengembalikan tarif kelas pajak dengan konfigurasi pajak setting
+func (t *TaxClass) RateFor(setting TaxSetting) decimal.Decimal {
+	if t.Rate.Valid {
+		return t.Rate.Decimal
+	}
+
+	return setting.PPNRate
+}
+
+// FindByCode mencari kelas pajak berdasarkan kode atau ID
+func (t *TaxClass) FindByCode(db *gorm.DB, code string) (*TaxClass, error) {
+	var class TaxClass
+
+	err := db.Debug().Model(&TaxClass{}).Where("code = ? OR id = ?", code, code).First(&class).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &class, nil
+}
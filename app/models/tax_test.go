@@ -0,0 +1,66 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/gieart87/gotoko/app/core/money"
+)
+
+func TestCalculateTaxLine(t *testing.T) {
+	exclusive := TaxSetting{Mode: TaxModeExclusive}
+	inclusive := TaxSetting{Mode: TaxModeInclusive}
+	ppn := decimal.NewFromInt(11)
+
+	tests := []struct {
+		name      string
+		price     int64
+		qty       int
+		discount  int64
+		rate      decimal.Decimal
+		setting   TaxSetting
+		basePrice string
+		baseTotal string
+		taxAmount string
+		subTotal  string
+	}{
+		{"tanpa PPN", 1000, 2, 0, decimal.Zero, exclusive, "1000", "2000", "0", "2000"},
+		{"exclusive pas", 10000, 3, 0, ppn, exclusive, "10000", "30000", "3300", "33300"},
+		{"exclusive dibulatkan ke atas", 9999, 1, 0, ppn, exclusive, "9999", "9999", "1100", "11099"},
+		{"exclusive setengah rupiah menjauhi nol", 50, 1, 0, ppn, exclusive, "50", "50", "6", "56"},
+		{"exclusive dengan potongan", 10000, 2, 500, ppn, exclusive, "10000", "19500", "2145", "21645"},
+		{"inclusive pas", 11100, 1, 0, ppn, inclusive, "10000", "10000", "1100", "11100"},
+		// Pajak per baris 10500*11/111 = 1040,54 dibulatkan 1041, bukan 7 x 149 = 1043 jika dibulatkan per satuan
+		{"inclusive dibulatkan per baris", 1500, 7, 0, ppn, inclusive, "1351.29", "9459", "1041", "10500"},
+		{"inclusive dengan potongan", 10000, 2, 500, ppn, inclusive, "9009", "17568", "1932", "19500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := CalculateTaxLine(money.FromInt(tt.price), tt.qty, money.FromInt(tt.discount), tt.rate, tt.setting)
+
+			assertMoney(t, "BasePrice", line.BasePrice, tt.basePrice)
+			assertMoney(t, "BaseTotal", line.BaseTotal, tt.baseTotal)
+			assertMoney(t, "TaxAmount", line.TaxAmount, tt.taxAmount)
+			assertMoney(t, "SubTotal", line.SubTotal, tt.subTotal)
+
+			if !line.BaseTotal.Add(line.TaxAmount).Equal(line.SubTotal) {
+				t.Errorf("BaseTotal %s + TaxAmount %s != SubTotal %s", line.BaseTotal, line.TaxAmount, line.SubTotal)
+			}
+		})
+	}
+}
+
+// assertMoney membandingkan nilai uang dengan nilai yang diharapkan dalam bentuk teks
+func assertMoney(t *testing.T, field string, got money.Money, want string) {
+	t.Helper()
+
+	expected, err := money.Parse(want)
+	if err != nil {
+		t.Fatalf("%s: nilai yang diharapkan %q tidak valid: %v", field, want, err)
+	}
+	if !got.Equal(expected) {
+		t.Errorf("%s = %s, want %s", field, got, want)
+	}
+}
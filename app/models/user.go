@@ -8,19 +8,20 @@ import (
 )
 
 type User struct {
-	ID            string `gorm:"size:36;not null;uniqueIndex;primary_key"`
-	RoleID        string `gorm:"size:36;index"`
-	Role          Role
-	Addresses     []Address
-	FirstName     string `gorm:"size:100;not null"`
-	LastName      string `gorm:"size:100;not null"`
-	Email         string `gorm:"size:100;not null;uniqueIndex"`
-	Password      string `gorm:"size:255;not null"`
-	RememberToken string `gorm:"size:255;not null"`
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	DeletedAt     gorm.DeletedAt
-	Phone         string `gorm:"size:255;not null"`
+	ID              string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	RoleID          string `gorm:"size:36;index"`
+	Role            Role
+	CustomerGroupID string `gorm:"size:36;index"` // Menggantikan kelompok pelanggan bawaan role
+	Addresses       []Address
+	FirstName       string `gorm:"size:100;not null"`
+	LastName        string `gorm:"size:100;not null"`
+	Email           string `gorm:"size:100;not null;uniqueIndex"`
+	Password        string `gorm:"size:255;not null"`
+	RememberToken   string `gorm:"size:255;not null"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       gorm.DeletedAt
+	Phone           string `gorm:"size:255;not null"`
 }
 
 func (u *User) FindByEmail(db *gorm.DB, email string) (*User, error) {
@@ -51,6 +52,28 @@ func (u *User) FindByID(db *gorm.DB, userID string) (*User, error) {
 	return &user, nil
 }
 
+// PriceGroupID mengembalikan kelompok pelanggan yang dipakai untuk harga: kelompok milik pengguna,
+// jika kosong kelompok bawaan role. Role harus sudah dimuat (lihat FindByID).
+func (u *User) PriceGroupID() string {
+	if u.CustomerGroupID != "" {
+		return u.CustomerGroupID
+	}
+
+	return u.Role.CustomerGroupID
+}
+
+// GetUsers mengambil semua pengguna beserta role-nya, urut berdasarkan nama
+func (u *User) GetUsers(db *gorm.DB) ([]User, error) {
+	var users []User
+
+	err := db.Debug().Preload("Role").Model(User{}).Order("first_name asc, last_name asc").Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
 func (u *User) CreateUser(db *gorm.DB, param *User) (*User, error) {
 	user := &User{
 		ID:        param.ID,
@@ -0,0 +1,339 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/gieart87/gotoko/app/consts"
+	"github.com/gieart87/gotoko/app/core/money"
+)
+
+// Jenis potongan voucher
+const (
+	VoucherTypePercent = "percent" // Potongan Value persen dari total item yang berlaku
+	VoucherTypeFixed   = "fixed"   // Potongan tetap Value rupiah
+)
+
+// VoucherTypes adalah jenis voucher beserta labelnya, dipakai di form admin
+var VoucherTypes = map[string]string{
+	VoucherTypePercent: "Potongan persen",
+	VoucherTypeFixed:   "Potongan rupiah",
+}
+
+// Voucher adalah kode promo yang memotong total belanja sebelum pajak. Batas yang bernilai nol
+// berarti tanpa batas, dan ProductID atau CategoryID yang kosong berarti berlaku untuk semua produk.
+type Voucher struct {
+	ID                string          `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	Code              string          `gorm:"size:50;not null;uniqueIndex"` // Selalu huruf besar, misalnya RAMADAN25
+	Name              string          `gorm:"size:100;not null"`
+	Type              string          `gorm:"size:20;not null"`
+	Value             decimal.Decimal `gorm:"type:decimal(16,2)"`
+	MinSpend          money.Money     `gorm:"type:decimal(16,2)"` // Minimal total item yang berlaku
+	MaxDiscount       money.Money     `gorm:"type:decimal(16,2)"` // Potongan maksimum untuk voucher persen
+	UsageLimit        int             // Batas pemakaian seluruh pelanggan
+	UsageLimitPerUser int             // Batas pemakaian tiap pelanggan
+	ProductID         string          `gorm:"size:36;index"`
+	CategoryID        string          `gorm:"size:36;index"`
+	StartsAt          sql.NullTime
+	EndsAt            sql.NullTime
+	Status            int `gorm:"default:1"` // 1 aktif, 0 nonaktif
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	DeletedAt         gorm.DeletedAt
+}
+
+// VoucherUsage mencatat pemakaian voucher pada satu order
+type VoucherUsage struct {
+	ID             string      `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	VoucherID      string      `gorm:"size:36;index"`
+	UserID         string      `gorm:"size:36;index"`
+	OrderID        string      `gorm:"size:36;index"`
+	DiscountAmount money.Money `gorm:"type:decimal(16,2)"`
+	CreatedAt      time.Time
+}
+
+func (v *Voucher) BeforeCreate(db *gorm.DB) error {
+	if v.ID == "" {
+		v.ID = uuid.New().String()
+	}
+
+	return nil
+}
+
+func (v *VoucherUsage) BeforeCreate(db *gorm.DB) error {
+	if v.ID == "" {
+		v.ID = uuid.New().String()
+	}
+
+	return nil
+}
+
+// NormalizeVoucherCode menyeragamkan kode voucher yang diketik pelanggan
+func NormalizeVoucherCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Validate memeriksa isian voucher sebelum disimpan
+func (v *Voucher) Validate() error {
+	if v.Code == "" {
+		return errors.New("Kode voucher wajib diisi")
+	}
+	if v.Name == "" {
+		return errors.New("Nama voucher wajib diisi")
+	}
+	if _, ok := VoucherTypes[v.Type]; !ok {
+		return errors.New("Jenis voucher tidak dikenal")
+	}
+	if !v.Value.IsPositive() {
+		return errors.New("Nilai potongan harus lebih dari 0")
+	}
+	if v.Type == VoucherTypePercent && v.Value.GreaterThan(decimal.NewFromInt(100)) {
+		return errors.New("Potongan persen tidak boleh lebih dari 100")
+	}
+	if v.MinSpend.IsNegative() || v.MaxDiscount.IsNegative() {
+		return errors.New("Minimal belanja dan potongan maksimum tidak boleh negatif")
+	}
+	if v.UsageLimit < 0 || v.UsageLimitPerUser < 0 {
+		return errors.New("Batas pemakaian tidak boleh negatif")
+	}
+	if v.StartsAt.Valid && v.EndsAt.Valid && !v.EndsAt.Time.After(v.StartsAt.Time) {
+		return errors.New("Waktu selesai harus setelah waktu mulai")
+	}
+
+	return nil
+}
+
+// TypeLabel mengembalikan label jenis voucher
+func (v *Voucher) TypeLabel() string {
+	return VoucherTypes[v.Type]
+}
+
+// IsActiveAt menandakan voucher aktif dan berada dalam periode berlakunya pada waktu at
+func (v *Voucher) IsActiveAt(at time.Time) bool {
+	if v.Status != 1 {
+		return false
+	}
+	if v.StartsAt.Valid && at.Before(v.StartsAt.Time) {
+		return false
+	}
+	if v.EndsAt.Valid && !at.Before(v.EndsAt.Time) {
+		return false
+	}
+
+	return true
+}
+
+// AppliesTo menandakan voucher berlaku untuk produk ini. Kategori yang dipilih berlaku juga untuk sub kategorinya.
+func (v *Voucher) AppliesTo(db *gorm.DB, product *Product) (bool, error) {
+	if v.ProductID != "" && v.ProductID != product.ID {
+		return false, nil
+	}
+	if v.CategoryID == "" {
+		return true, nil
+	}
+
+	categoryIDs, err := product.GetCategoryIDs(db)
+	if err != nil {
+		return false, err
+	}
+	for _, categoryID := range categoryIDs {
+		if categoryID == v.CategoryID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Discount menghitung potongan untuk total item yang berlaku, dibulatkan ke rupiah dan tidak melebihi total tersebut
+func (v *Voucher) Discount(eligibleTotal money.Money) money.Money {
+	var discount money.Money
+	switch v.Type {
+	case VoucherTypePercent:
+		discount = eligibleTotal.Percent(v.Value).Round()
+		if v.MaxDiscount.IsPositive() && v.MaxDiscount.LessThan(discount) {
+			discount = v.MaxDiscount
+		}
+	case VoucherTypeFixed:
+		discount = money.New(v.Value)
+	default:
+		return money.Zero
+	}
+
+	if eligibleTotal.LessThan(discount) {
+		return eligibleTotal
+	}
+
+	return discount
+}
+
+// VoucherUsageError dikembalikan jika batas pemakaian voucher sudah habis
+type VoucherUsageError struct {
+	Message string
+}
+
+func (e *VoucherUsageError) Error() string {
+	return e.Message
+}
+
+// CheckUsage memastikan batas pemakaian voucher belum habis, baik total maupun untuk pelanggan userID.
+// Pemakaian pada order yang dibatalkan tidak dihitung. Saat checkout, voucher harus dikunci dengan
+// FindByCodeForUpdate dalam transaksi yang sama dengan pencatatan VoucherUsage.
+func (v *Voucher) CheckUsage(db *gorm.DB, userID string) error {
+	usages := func() *gorm.DB {
+		return db.Debug().Model(&VoucherUsage{}).
+			Joins("JOIN orders ON orders.id = voucher_usages.order_id").
+			Where("voucher_usages.voucher_id = ? AND orders.status <> ?", v.ID, consts.OrderStatusCancelled)
+	}
+
+	if v.UsageLimit > 0 {
+		var count int64
+		if err := usages().Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= int64(v.UsageLimit) {
+			return &VoucherUsageError{Message: "Kuota voucher " + v.Code + " sudah habis"}
+		}
+	}
+
+	if v.UsageLimitPerUser > 0 {
+		var count int64
+		if err := usages().Where("voucher_usages.user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= int64(v.UsageLimitPerUser) {
+			return &VoucherUsageError{Message: fmt.Sprintf("Voucher %s hanya bisa dipakai %d kali per pelanggan", v.Code, v.UsageLimitPerUser)}
+		}
+	}
+
+	return nil
+}
+
+// CheckCart memastikan voucher bisa dipakai pada keranjang untuk pelanggan userID
+func (v *Voucher) CheckCart(db *gorm.DB, cart *Cart, userID string) error {
+	if !v.IsActiveAt(time.Now()) {
+		return errors.New("Voucher " + v.Code + " tidak berlaku saat ini")
+	}
+
+	eligibleTotal, err := v.eligibleTotal(db, cart.CartItems)
+	if err != nil {
+		return err
+	}
+	if eligibleTotal.IsZero() {
+		return errors.New("Voucher " + v.Code + " tidak berlaku untuk produk di keranjang")
+	}
+	if eligibleTotal.LessThan(v.MinSpend) {
+		return fmt.Errorf("Voucher %s membutuhkan minimal belanja Rp %s", v.Code, v.MinSpend.StringFixed(0))
+	}
+
+	return v.CheckUsage(db, userID)
+}
+
+// eligibleTotal menjumlahkan total harga item yang termasuk cakupan voucher, sebelum pajak dan potongan
+func (v *Voucher) eligibleTotal(db *gorm.DB, items []CartItem) (money.Money, error) {
+	total := money.Zero
+	for _, item := range items {
+		applies, err := v.AppliesTo(db, &item.Product)
+		if err != nil {
+			return money.Zero, err
+		}
+		if applies {
+			total = total.Add(item.Pricenew.Times(item.Qty))
+		}
+	}
+
+	return total, nil
+}
+
+// Allocate membagi potongan voucher ke item yang berlaku sebanding dengan total harganya. Sisa pembulatan
+// diberikan ke item terakhir agar jumlah potongan item sama dengan potongan voucher. Hasilnya per ID item.
+func (v *Voucher) Allocate(db *gorm.DB, items []CartItem) (map[string]money.Money, error) {
+	eligibleTotal, err := v.eligibleTotal(db, items)
+	if err != nil {
+		return nil, err
+	}
+
+	discounts := map[string]money.Money{}
+	if !eligibleTotal.IsPositive() || eligibleTotal.LessThan(v.MinSpend) {
+		return discounts, nil
+	}
+
+	discount := v.Discount(eligibleTotal)
+	remaining := discount
+	var lastID string
+	for _, item := range items {
+		applies, err := v.AppliesTo(db, &item.Product)
+		if err != nil {
+			return nil, err
+		}
+		if !applies {
+			continue
+		}
+
+		share := money.New(discount.Mul(item.Pricenew.Times(item.Qty).Decimal).Div(eligibleTotal.Decimal)).Round()
+		discounts[item.ID] = share
+		remaining = remaining.Sub(share)
+		lastID = item.ID
+	}
+	discounts[lastID] = discounts[lastID].Add(remaining)
+
+	return discounts, nil
+}
+
+// GetVouchers mengambil semua voucher untuk halaman admin
+func (v *Voucher) GetVouchers(db *gorm.DB) ([]Voucher, error) {
+	var vouchers []Voucher
+
+	err := db.Debug().Model(&Voucher{}).Order("created_at desc").Find(&vouchers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return vouchers, nil
+}
+
+func (v *Voucher) FindByID(db *gorm.DB, voucherID string) (*Voucher, error) {
+	var voucher Voucher
+
+	err := db.Debug().Model(&Voucher{}).Where("id = ?", voucherID).First(&voucher).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &voucher, nil
+}
+
+// FindByCode mencari voucher berdasarkan kode yang diketik pelanggan
+func (v *Voucher) FindByCode(db *gorm.DB, code string) (*Voucher, error) {
+	var voucher Voucher
+
+	err := db.Debug().Model(&Voucher{}).Where("code = ?", NormalizeVoucherCode(code)).First(&voucher).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &voucher, nil
+}
+
+// FindByCodeForUpdate mencari voucher berdasarkan kode dan mengunci barisnya sampai transaksi database selesai,
+// agar checkout bersamaan tidak melewati batas pemakaian voucher
+func (v *Voucher) FindByCodeForUpdate(tx *gorm.DB, code string) (*Voucher, error) {
+	var voucher Voucher
+
+	err := tx.Debug().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Model(&Voucher{}).Where("code = ?", NormalizeVoucherCode(code)).
+		First(&voucher).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &voucher, nil
+}
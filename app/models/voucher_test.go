@@ -0,0 +1,127 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/gieart87/gotoko/app/consts"
+	"github.com/gieart87/gotoko/app/core/money"
+)
+
+func TestVoucherAllocate(t *testing.T) {
+	item := func(id string, price int64, qty int) CartItem {
+		return CartItem{ID: id, ProductID: id, Product: Product{ID: id}, Pricenew: money.FromInt(price), Qty: qty}
+	}
+
+	tests := []struct {
+		name    string
+		voucher Voucher
+		items   []CartItem
+		want    map[string]string
+	}{
+		{
+			name:    "sisa pembulatan ke item terakhir",
+			voucher: Voucher{Type: VoucherTypeFixed, Value: decimal.NewFromInt(100)},
+			items:   []CartItem{item("a", 1000, 1), item("b", 1000, 1), item("c", 1000, 1)},
+			want:    map[string]string{"a": "33", "b": "33", "c": "34"},
+		},
+		{
+			name:    "kelebihan pembulatan dikurangi dari item terakhir",
+			voucher: Voucher{Type: VoucherTypeFixed, Value: decimal.NewFromInt(100)},
+			items:   []CartItem{item("a", 1000, 1), item("b", 1000, 1), item("c", 1000, 1), item("d", 1000, 3)},
+			want:    map[string]string{"a": "17", "b": "17", "c": "17", "d": "49"},
+		},
+		{
+			name:    "persen sebanding total item",
+			voucher: Voucher{Type: VoucherTypePercent, Value: decimal.NewFromInt(10)},
+			items:   []CartItem{item("a", 1500, 3), item("b", 2250, 1)},
+			want:    map[string]string{"a": "450", "b": "225"},
+		},
+		{
+			name:    "persen dibatasi potongan maksimum",
+			voucher: Voucher{Type: VoucherTypePercent, Value: decimal.NewFromInt(50), MaxDiscount: money.FromInt(1000)},
+			items:   []CartItem{item("a", 1000, 1), item("b", 2000, 1)},
+			want:    map[string]string{"a": "333", "b": "667"},
+		},
+		{
+			name:    "hanya produk yang berlaku",
+			voucher: Voucher{Type: VoucherTypeFixed, Value: decimal.NewFromInt(100), ProductID: "b"},
+			items:   []CartItem{item("a", 1000, 1), item("b", 1000, 1)},
+			want:    map[string]string{"b": "100"},
+		},
+		{
+			name:    "minimal belanja belum terpenuhi",
+			voucher: Voucher{Type: VoucherTypeFixed, Value: decimal.NewFromInt(100), MinSpend: money.FromInt(5000)},
+			items:   []CartItem{item("a", 1000, 3)},
+			want:    map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Voucher tanpa kategori tidak membaca database
+			discounts, err := tt.voucher.Allocate(nil, tt.items)
+			if err != nil {
+				t.Fatalf("Allocate: %v", err)
+			}
+
+			if len(discounts) != len(tt.want) {
+				t.Fatalf("Allocate = %v, want %v", discounts, tt.want)
+			}
+
+			total := money.Zero
+			for id, want := range tt.want {
+				assertMoney(t, "potongan item "+id, discounts[id], want)
+				total = total.Add(discounts[id])
+			}
+
+			if len(tt.want) > 0 {
+				eligibleTotal, _ := tt.voucher.eligibleTotal(nil, tt.items)
+				if discount := tt.voucher.Discount(eligibleTotal); !total.Equal(discount) {
+					t.Errorf("jumlah potongan item %s != potongan voucher %s", total, discount)
+				}
+			}
+		})
+	}
+}
+
+func TestVoucherCheckUsage(t *testing.T) {
+	db := newTestDB(t)
+
+	voucher := Voucher{Code: "HEMAT", Type: VoucherTypeFixed, Value: decimal.NewFromInt(100), UsageLimit: 2, UsageLimitPerUser: 1}
+	if err := db.Create(&voucher).Error; err != nil {
+		t.Fatalf("create voucher: %v", err)
+	}
+
+	use := func(orderID string, userID string, status int) {
+		t.Helper()
+		if err := db.Create(&Order{ID: orderID, Code: orderID, UserID: userID, Status: status}).Error; err != nil {
+			t.Fatalf("create order: %v", err)
+		}
+		if err := db.Create(&VoucherUsage{VoucherID: voucher.ID, UserID: userID, OrderID: orderID}).Error; err != nil {
+			t.Fatalf("create usage: %v", err)
+		}
+	}
+
+	// Pemakaian pada order yang dibatalkan tidak dihitung
+	use("O1", "U1", consts.OrderStatusCancelled)
+	if err := voucher.CheckUsage(db, "U1"); err != nil {
+		t.Fatalf("CheckUsage setelah order batal: %v", err)
+	}
+
+	use("O2", "U1", consts.OrderStatusPending)
+	var usageErr *VoucherUsageError
+	if err := voucher.CheckUsage(db, "U1"); !errors.As(err, &usageErr) {
+		t.Fatalf("CheckUsage melewati batas per pelanggan: %v", err)
+	}
+	if err := voucher.CheckUsage(db, "U2"); err != nil {
+		t.Fatalf("CheckUsage pelanggan lain: %v", err)
+	}
+
+	use("O3", "U2", consts.OrderStatusReceived)
+	if err := voucher.CheckUsage(db, "U3"); !errors.As(err, &usageErr) {
+		t.Fatalf("CheckUsage melewati batas total: %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package policies
+
+import (
+	"github.com/gieart87/gotoko/app/consts"
+	"github.com/gieart87/gotoko/app/models"
+)
+
+// IsAdmin menandakan user adalah admin yang boleh melihat data semua pelanggan. Role user harus sudah dimuat.
+func IsAdmin(user *models.User) bool {
+	return user != nil && user.Role.Name == consts.RoleAdmin
+}
+
+// CanViewOrder menandakan user boleh melihat atau membayar order beserta alamat pengirimannya: pemilik order atau admin.
+// Controller membalas 404 jika tidak boleh, sama seperti order yang tidak ada, agar ID order pelanggan lain tidak bisa ditebak.
+func CanViewOrder(user *models.User, order *models.Order) bool {
+	if user == nil {
+		return false
+	}
+
+	return order.UserID == user.ID || IsAdmin(user)
+}
+
+// CanModifyCartItem menandakan item termasuk keranjang cart. Keranjang diambil dari sesi, jadi admin pun
+// hanya bisa mengubah item keranjangnya sendiri.
+func CanModifyCartItem(cart *models.Cart, item *models.CartItem) bool {
+	return item.CartID == cart.ID
+}
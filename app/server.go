@@ -2,7 +2,6 @@ package app
 
 import (
 	"flag"
-	"fmt"
 	"log"
 	"os"
 
@@ -33,13 +32,19 @@ func Run() {
 	appConfig.AppEnv = getEnv("APP_ENV", "development")
 	appConfig.AppPort = getEnv("APP_PORT", "9000")
 	appConfig.AppURL = getEnv("APP_URL", "https://tokoshafirda.web.id")
+	appConfig.ShippingProvider = getEnv("SHIPPING_PROVIDER", "biteship")
+	appConfig.PaymentGateway = getEnv("PAYMENT_GATEWAY", "midtrans")
+	appConfig.TaxPPNRate = getEnv("TAX_PPN_RATE", "0")
+	appConfig.TaxPriceMode = getEnv("TAX_PRICE_MODE", "exclusive")
+	appConfig.StockHoldDuration = getEnv("STOCK_HOLD_DURATION", "24h")
+	appConfig.StockSweepInterval = getEnv("STOCK_SWEEP_INTERVAL", "1m")
 
 	dbConfig.DBHost = getEnv("DB_HOST", "localhost")
 	dbConfig.DBUser = getEnv("DB_USER", "gotoko")
 	dbConfig.DBPassword = getEnv("DB_PASSWORD", "1112030123")
 	dbConfig.DBName = getEnv("DB_NAME", "tokoshafirda")
 	dbConfig.DBPort = getEnv("DB_PORT", "3306")
-	fmt.Sprint("%dbConfig")
+	dbConfig.DBDriver = getEnv("DB_DRIVER", "mysql")
 	flag.Parse()
 	arg := flag.Arg(0)
 
@@ -25,12 +25,17 @@ func ProductFaker(db *gorm.DB) *models.Product {
 	}
 
 	name := faker.Name()
+	price := decimal.NewFromFloat(fakePrice())
+	units := []models.ProductUnit{
+		{Name: "Pcs", Conversion: 1, RetailPrice: price, WholesalePrice: price, SortOrder: 1},
+	}
 	return &models.Product{
 		ID:               uuid.New().String(),
 		Sku:              slug.Make(name),
 		Name:             name,
 		Slug:             slug.Make(name),
-		Price:            decimal.NewFromFloat(fakePrice()),
+		Price:            price,
+		Units:            units,
 		Stock:            rand.Intn(100),
 		Weight:           decimal.NewFromFloat(rand.Float64()),
 		ShortDescription: faker.Paragraph(),
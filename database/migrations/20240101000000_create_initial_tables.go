@@ -0,0 +1,293 @@
+package migrations
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Struct di bawah adalah salinan skema tabel saat migrasi ini dibuat.
+// Jangan diubah mengikuti perubahan model, buat migrasi baru sebagai gantinya.
+
+type initialRole struct {
+	ID          string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	Name        string `gorm:"size:100;not null;index"`
+	Description string `gorm:"size:255"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt
+}
+
+func (initialRole) TableName() string { return "roles" }
+
+type initialUser struct {
+	ID            string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	RoleID        string `gorm:"size:36;index"`
+	FirstName     string `gorm:"size:100;not null"`
+	LastName      string `gorm:"size:100;not null"`
+	Email         string `gorm:"size:100;not null;uniqueIndex"`
+	Password      string `gorm:"size:255;not null"`
+	RememberToken string `gorm:"size:255;not null"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt
+	Phone         string `gorm:"size:255;not null"`
+}
+
+func (initialUser) TableName() string { return "users" }
+
+type initialAddress struct {
+	ID         string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	UserID     string `gorm:"size:36;index"`
+	Name       string `gorm:"size:100"`
+	IsPrimary  bool
+	CityID     string `gorm:"size:100"`
+	ProvinceID string `gorm:"size:100"`
+	Address1   string `gorm:"size:255"`
+	Address2   string `gorm:"size:255"`
+	Phone      string `gorm:"size:100"`
+	Email      string `gorm:"size:100"`
+	PostCode   string `gorm:"size:100"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (initialAddress) TableName() string { return "addresses" }
+
+type initialProduct struct {
+	ID               string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	ParentID         string `gorm:"size:36;index"`
+	Name             string `gorm:"size:255"`
+	SATUAN1          string `gorm:"size:255"`
+	SATUAN2          string `gorm:"size:255"`
+	SATUAN3          string `gorm:"size:255"`
+	KONVERSI1        int
+	KONVERSI2        int
+	KONVERSI3        int
+	HARGAPOKOK1      decimal.Decimal `gorm:"type:decimal(16,2);"`
+	HARGAPOKOK2      decimal.Decimal `gorm:"type:decimal(16,2);"`
+	HARGAPOKOK3      decimal.Decimal `gorm:"type:decimal(16,2);"`
+	HJ1              decimal.Decimal `gorm:"type:decimal(16,2);"`
+	HJ2              decimal.Decimal `gorm:"type:decimal(16,2);"`
+	HJ3              decimal.Decimal `gorm:"type:decimal(16,2);"`
+	HJ2_1            decimal.Decimal `gorm:"type:decimal(16,2);"`
+	HJ2_2            decimal.Decimal `gorm:"type:decimal(16,2);"`
+	HJ2_3            decimal.Decimal `gorm:"type:decimal(16,2);"`
+	Stock            int
+	Supplier         string          `gorm:"type:text"`
+	Categories       string          `gorm:"size:255"`
+	Sku              string          `gorm:"size:100;index"`
+	Slug             string          `gorm:"size:255"`
+	Price            decimal.Decimal `gorm:"type:decimal(16,2);"`
+	Weight           decimal.Decimal `gorm:"type:decimal(10,2);"`
+	ShortDescription string          `gorm:"type:text"`
+	Description      string          `gorm:"type:text"`
+	Status           int             `gorm:"default:0"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	DeletedAt        gorm.DeletedAt
+}
+
+func (initialProduct) TableName() string { return "products" }
+
+type initialProductImage struct {
+	ID         string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	ProductID  string `gorm:"size:36;index"`
+	Path       string `gorm:"type:text"`
+	ExtraLarge string `gorm:"type:text"`
+	Large      string `gorm:"type:text"`
+	Medium     string `gorm:"type:text"`
+	Small      string `gorm:"type:text"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (initialProductImage) TableName() string { return "product_images" }
+
+type initialOrder struct {
+	ID                  string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	UserID              string `gorm:"size:36;index"`
+	Code                string `gorm:"size:50;index"`
+	Status              int
+	OrderDate           time.Time
+	PaymentDue          time.Time
+	PaymentStatus       string          `gorm:"size:50;index"`
+	PaymentToken        sql.NullString  `gorm:"size:100;index"`
+	BaseTotalPrice      decimal.Decimal `gorm:"type:decimal(16,2)"`
+	TaxAmount           decimal.Decimal `gorm:"type:decimal(16,2)"`
+	TaxPercent          decimal.Decimal `gorm:"type:decimal(10,2)"`
+	DiscountAmount      decimal.Decimal `gorm:"type:decimal(16,2)"`
+	DiscountPercent     decimal.Decimal `gorm:"type:decimal(10,2)"`
+	ShippingCost        decimal.Decimal `gorm:"type:decimal(16,2)"`
+	GrandTotal          decimal.Decimal `gorm:"type:decimal(16,2)"`
+	Note                string          `gorm:"type:text"`
+	ShippingCourier     string          `gorm:"size:100"`
+	ShippingServiceName string          `gorm:"size:100"`
+	ApprovedBy          sql.NullString  `gorm:"size:36"`
+	ApprovedAt          sql.NullTime
+	CancelledBy         sql.NullString `gorm:"size:36"`
+	CancelledAt         sql.NullTime
+	CancellationNote    sql.NullString `gorm:"size:255"`
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	DeletedAt           gorm.DeletedAt
+}
+
+func (initialOrder) TableName() string { return "orders" }
+
+type initialOrderItem struct {
+	ID              string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	OrderID         string `gorm:"size:36;index"`
+	ProductID       string `gorm:"size:36;index"`
+	Qty             int
+	Unit            string `gorm:"size:50"`
+	Pricenew        int
+	BasePrice       decimal.Decimal `gorm:"type:decimal(16,2)"`
+	BaseTotal       decimal.Decimal `gorm:"type:decimal(16,2)"`
+	TaxAmount       decimal.Decimal `gorm:"type:decimal(16,2)"`
+	TaxPercent      decimal.Decimal `gorm:"type:decimal(10,2)"`
+	DiscountAmount  decimal.Decimal `gorm:"type:decimal(16,2)"`
+	DiscountPercent decimal.Decimal `gorm:"type:decimal(10,2)"`
+	SubTotal        decimal.Decimal `gorm:"type:decimal(16,2)"`
+	Sku             string          `gorm:"size:36;index"`
+	Name            string          `gorm:"size:255"`
+	Weight          decimal.Decimal `gorm:"type:decimal(10,2)"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (initialOrderItem) TableName() string { return "order_items" }
+
+type initialOrderCustomer struct {
+	ID         string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	UserID     string `gorm:"size:36;index"`
+	OrderID    string `gorm:"size:36;index"`
+	FirstName  string `gorm:"size:100;not null"`
+	LastName   string `gorm:"size:100;not null"`
+	CityID     string `gorm:"size:100;"`
+	ProvinceID string `gorm:"size:100;"`
+	Address1   string `gorm:"size:100;"`
+	Address2   string `gorm:"size:100;"`
+	Phone      string `gorm:"size:50;"`
+	Email      string `gorm:"size:100;"`
+	PostCode   string `gorm:"size:100;"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (initialOrderCustomer) TableName() string { return "order_customers" }
+
+type initialPayment struct {
+	ID                string           `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	OrderID           string           `gorm:"size:36;index"`
+	Number            string           `gorm:"size:100;index"`
+	Amount            decimal.Decimal  `gorm:"type:decimal(16,2)"`
+	TransactionID     string           `gorm:"size:100;index;index:idx_payment_transaction,priority:1"`
+	TransactionStatus string           `gorm:"size:100;index;index:idx_payment_transaction,priority:2"`
+	Payload           *json.RawMessage `gorm:"type:json;not null;"`
+	PaymentType       string           `gorm:"size:100"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	DeletedAt         gorm.DeletedAt
+}
+
+func (initialPayment) TableName() string { return "payments" }
+
+type initialShipment struct {
+	ID          string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	UserID      string `gorm:"size:36;index"`
+	OrderID     string `gorm:"size:36;index"`
+	TrackNumber string `gorm:"size:255;index"`
+	Status      string `gorm:"size:36;index"`
+	TotalQty    int
+	TotalWeight decimal.Decimal `gorm:"type:decimal(10,2);"`
+	FirstName   string          `gorm:"size:100;not null"`
+	LastName    string          `gorm:"size:100;not null"`
+	CityID      string          `gorm:"size:100;"`
+	ProvinceID  string          `gorm:"size:100;"`
+	Address1    string          `gorm:"size:100;"`
+	Address2    string          `gorm:"size:100;"`
+	Phone       string          `gorm:"size:50;"`
+	Email       string          `gorm:"size:100;"`
+	PostCode    string          `gorm:"size:100;"`
+	ShippedBy   string          `gorm:"size:36"`
+	ShippedAt   time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt
+}
+
+func (initialShipment) TableName() string { return "shipments" }
+
+type initialCart struct {
+	ID              string          `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	BaseTotalPrice  decimal.Decimal `gorm:"type:decimal(16,2)"`
+	TaxAmount       decimal.Decimal `gorm:"type:decimal(16,2)"`
+	TaxPercent      decimal.Decimal `gorm:"type:decimal(10,2)"`
+	DiscountAmount  decimal.Decimal `gorm:"type:decimal(16,2)"`
+	DiscountPercent decimal.Decimal `gorm:"type:decimal(10,2)"`
+	GrandTotal      decimal.Decimal `gorm:"type:decimal(16,2)"`
+}
+
+func (initialCart) TableName() string { return "carts" }
+
+type initialCartItem struct {
+	ID              string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	CartID          string `gorm:"size:36;index"`
+	ProductID       string `gorm:"size:36;index"`
+	Qty             int
+	Unit            string          `gorm:"size:50"`
+	BasePrice       decimal.Decimal `gorm:"type:decimal(16,2)"`
+	BaseTotal       decimal.Decimal `gorm:"type:decimal(16,2)"`
+	TaxAmount       decimal.Decimal `gorm:"type:decimal(16,2)"`
+	TaxPercent      decimal.Decimal `gorm:"type:decimal(10,2)"`
+	DiscountAmount  decimal.Decimal `gorm:"type:decimal(16,2)"`
+	DiscountPercent decimal.Decimal `gorm:"type:decimal(10,2)"`
+	SubTotal        decimal.Decimal `gorm:"type:decimal(16,2)"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Pricenew        int
+}
+
+func (initialCartItem) TableName() string { return "cart_items" }
+
+func initialTables() []interface{} {
+	return []interface{}{
+		&initialRole{},
+		&initialUser{},
+		&initialAddress{},
+		&initialProduct{},
+		&initialProductImage{},
+		&initialOrder{},
+		&initialOrderItem{},
+		&initialOrderCustomer{},
+		&initialPayment{},
+		&initialShipment{},
+		&initialCart{},
+		&initialCartItem{},
+	}
+}
+
+func init() {
+	register(Migration{
+		Version: "20240101000000_create_initial_tables",
+		Up: func(tx *gorm.DB) error {
+			// AutoMigrate dipakai agar database yang sudah berjalan sebelum ada migrasi
+			// cukup disesuaikan tanpa membuat ulang tabel
+			return tx.AutoMigrate(initialTables()...)
+		},
+		Down: func(tx *gorm.DB) error {
+			tables := initialTables()
+			for i := len(tables) - 1; i >= 0; i-- {
+				if err := tx.Migrator().DropTable(tables[i]); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	})
+}
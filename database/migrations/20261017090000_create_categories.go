@@ -0,0 +1,102 @@
+package migrations
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gosimple/slug"
+	"gorm.io/gorm"
+)
+
+type categoryTable struct {
+	ID        string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	ParentID  string `gorm:"size:36;index"`
+	Name      string `gorm:"size:100;not null"`
+	Slug      string `gorm:"size:100;not null;uniqueIndex"`
+	SortOrder int    `gorm:"default:0"`
+	Image     string `gorm:"type:text"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt
+}
+
+func (categoryTable) TableName() string { return "categories" }
+
+type productCategoryTable struct {
+	ProductID  string `gorm:"size:36;primaryKey"`
+	CategoryID string `gorm:"size:36;primaryKey;index"`
+}
+
+func (productCategoryTable) TableName() string { return "product_categories" }
+
+func init() {
+	register(Migration{
+		Version: "20261017090000_create_categories",
+		Up: func(tx *gorm.DB) error {
+			err := tx.Migrator().CreateTable(&categoryTable{}, &productCategoryTable{})
+			if err != nil {
+				return err
+			}
+
+			// Pindahkan isi kolom teks products.categories menjadi baris kategori dan relasinya
+			rows, err := tx.Raw("SELECT DISTINCT categories FROM products WHERE categories IS NOT NULL AND categories <> ''").Rows()
+			if err != nil {
+				return err
+			}
+			var names []string
+			for rows.Next() {
+				var name string
+				if err := rows.Scan(&name); err != nil {
+					rows.Close()
+					return err
+				}
+				names = append(names, name)
+			}
+			rows.Close()
+
+			categoryIDs := map[string]string{}
+			for _, name := range names {
+				categorySlug := slug.Make(name)
+				if categorySlug == "" {
+					continue
+				}
+
+				categoryID, ok := categoryIDs[categorySlug]
+				if !ok {
+					category := categoryTable{ID: uuid.New().String(), Name: strings.TrimSpace(name), Slug: categorySlug}
+					if err := tx.Create(&category).Error; err != nil {
+						return err
+					}
+					categoryID = category.ID
+					categoryIDs[categorySlug] = categoryID
+				}
+
+				err := tx.Exec("INSERT INTO product_categories (product_id, category_id) SELECT id, ? FROM products WHERE categories = ?", categoryID, name).Error
+				if err != nil {
+					return err
+				}
+			}
+
+			return tx.Exec("ALTER TABLE products DROP COLUMN categories").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			err := tx.Migrator().AddColumn(&initialProduct{}, "Categories")
+			if err != nil {
+				return err
+			}
+
+			err = tx.Exec(`UPDATE products SET categories = (
+				SELECT categories.name FROM categories
+				JOIN product_categories ON product_categories.category_id = categories.id
+				WHERE product_categories.product_id = products.id
+				ORDER BY categories.name LIMIT 1
+			)`).Error
+			if err != nil {
+				return err
+			}
+
+			return tx.Migrator().DropTable(&productCategoryTable{}, &categoryTable{})
+		},
+	})
+}
@@ -0,0 +1,184 @@
+package migrations
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type productUnitTable struct {
+	ID             string          `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	ProductID      string          `gorm:"size:36;index;uniqueIndex:idx_product_unit_name,priority:1"`
+	Name           string          `gorm:"size:50;not null;uniqueIndex:idx_product_unit_name,priority:2"`
+	Conversion     int             `gorm:"not null;default:1"`
+	CostPrice      decimal.Decimal `gorm:"type:decimal(16,2)"`
+	RetailPrice    decimal.Decimal `gorm:"type:decimal(16,2)"`
+	WholesalePrice decimal.Decimal `gorm:"type:decimal(16,2)"`
+	Barcode        string          `gorm:"size:100;index"`
+	SortOrder      int             `gorm:"default:0"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (productUnitTable) TableName() string { return "product_units" }
+
+// legacyUnitColumns adalah kolom satuan lama di tabel products, urut per satuan ke-1 sampai ke-3:
+// nama, konversi, harga pokok, harga jual grosir (qty > 2) dan harga jual eceran
+var legacyUnitColumns = [3][5]string{
+	{"satuan1", "konversi1", "hargapokok1", "hj1", "hj2_1"},
+	{"satuan2", "konversi2", "hargapokok2", "hj2", "hj2_2"},
+	{"satuan3", "konversi3", "hargapokok3", "hj3", "hj2_3"},
+}
+
+var legacyUnitFields = []string{
+	"SATUAN1", "SATUAN2", "SATUAN3",
+	"KONVERSI1", "KONVERSI2", "KONVERSI3",
+	"HARGAPOKOK1", "HARGAPOKOK2", "HARGAPOKOK3",
+	"HJ1", "HJ2", "HJ3",
+	"HJ2_1", "HJ2_2", "HJ2_3",
+}
+
+type cartItemUnitColumn struct {
+	ProductUnitID string `gorm:"size:36;index"`
+}
+
+func (cartItemUnitColumn) TableName() string { return "cart_items" }
+
+type orderItemUnitColumn struct {
+	ProductUnitID string `gorm:"size:36;index"`
+}
+
+func (orderItemUnitColumn) TableName() string { return "order_items" }
+
+func init() {
+	register(Migration{
+		Version: "20261017100000_create_product_units",
+		Up: func(tx *gorm.DB) error {
+			err := tx.Migrator().CreateTable(&productUnitTable{})
+			if err != nil {
+				return err
+			}
+
+			for index, columns := range legacyUnitColumns {
+				if err := copyLegacyUnits(tx, index, columns); err != nil {
+					return err
+				}
+			}
+
+			err = tx.Migrator().AddColumn(&cartItemUnitColumn{}, "ProductUnitID")
+			if err != nil {
+				return err
+			}
+			err = tx.Migrator().AddColumn(&orderItemUnitColumn{}, "ProductUnitID")
+			if err != nil {
+				return err
+			}
+
+			// Isi satuan pada item keranjang dan order berdasarkan nama satuan yang tersimpan
+			for _, table := range []string{"cart_items", "order_items"} {
+				err = tx.Exec(fmt.Sprintf(`UPDATE %[1]s SET product_unit_id = (
+					SELECT product_units.id FROM product_units
+					WHERE product_units.product_id = %[1]s.product_id AND product_units.name = %[1]s.unit
+				)`, table)).Error
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, columns := range legacyUnitColumns {
+				for _, column := range columns {
+					if err := tx.Exec("ALTER TABLE products DROP COLUMN " + column).Error; err != nil {
+						return err
+					}
+				}
+			}
+
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, field := range legacyUnitFields {
+				if err := tx.Migrator().AddColumn(&initialProduct{}, field); err != nil {
+					return err
+				}
+			}
+
+			// Hanya tiga satuan pertama yang bisa dikembalikan ke kolom lama
+			for index, columns := range legacyUnitColumns {
+				err := tx.Exec(fmt.Sprintf(`UPDATE products SET %s = (SELECT name FROM product_units u WHERE u.product_id = products.id AND u.sort_order = ?),
+					%s = (SELECT conversion FROM product_units u WHERE u.product_id = products.id AND u.sort_order = ?),
+					%s = (SELECT cost_price FROM product_units u WHERE u.product_id = products.id AND u.sort_order = ?),
+					%s = (SELECT wholesale_price FROM product_units u WHERE u.product_id = products.id AND u.sort_order = ?),
+					%s = (SELECT retail_price FROM product_units u WHERE u.product_id = products.id AND u.sort_order = ?)`,
+					columns[0], columns[1], columns[2], columns[3], columns[4]),
+					index+1, index+1, index+1, index+1, index+1).Error
+				if err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Migrator().DropColumn(&orderItemUnitColumn{}, "ProductUnitID"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&cartItemUnitColumn{}, "ProductUnitID"); err != nil {
+				return err
+			}
+
+			return tx.Migrator().DropTable(&productUnitTable{})
+		},
+	})
+}
+
+// copyLegacyUnits membuat baris product_units dari satu kelompok kolom satuan lama
+func copyLegacyUnits(tx *gorm.DB, index int, columns [5]string) error {
+	rows, err := tx.Raw(fmt.Sprintf(
+		"SELECT id, %s, %s, %s, %s, %s FROM products WHERE %s IS NOT NULL AND %s <> ''",
+		columns[0], columns[1], columns[2], columns[3], columns[4], columns[0], columns[0],
+	)).Rows()
+	if err != nil {
+		return err
+	}
+
+	var units []productUnitTable
+	for rows.Next() {
+		var unit productUnitTable
+		var conversion *int
+		var cost, wholesale, retail decimal.NullDecimal
+		if err := rows.Scan(&unit.ProductID, &unit.Name, &conversion, &cost, &wholesale, &retail); err != nil {
+			rows.Close()
+			return err
+		}
+
+		unit.ID = uuid.New().String()
+		unit.Conversion = 1
+		if conversion != nil && *conversion > 0 {
+			unit.Conversion = *conversion
+		}
+		unit.CostPrice = cost.Decimal
+		unit.WholesalePrice = wholesale.Decimal
+		unit.RetailPrice = retail.Decimal
+		unit.SortOrder = index + 1
+		units = append(units, unit)
+	}
+	rows.Close()
+
+	for _, unit := range units {
+		// Lewati nama satuan yang sama dalam satu produk, misalnya SATUAN2 yang sama dengan SATUAN1
+		var count int64
+		err := tx.Model(&productUnitTable{}).Where("product_id = ? AND name = ?", unit.ProductID, unit.Name).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		if err := tx.Create(&unit).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
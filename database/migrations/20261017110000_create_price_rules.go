@@ -0,0 +1,82 @@
+package migrations
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type priceRuleTable struct {
+	ID         string          `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	Name       string          `gorm:"size:100;not null"`
+	ProductID  string          `gorm:"size:36;index"`
+	UnitName   string          `gorm:"size:50"`
+	CategoryID string          `gorm:"size:36;index"`
+	RoleID     string          `gorm:"size:36;index"`
+	MinQty     int             `gorm:"not null;default:1"`
+	Type       string          `gorm:"size:20;not null"`
+	Value      decimal.Decimal `gorm:"type:decimal(16,2)"`
+	StartsAt   sql.NullTime
+	EndsAt     sql.NullTime
+	Status     int `gorm:"default:1"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  gorm.DeletedAt
+}
+
+func (priceRuleTable) TableName() string { return "price_rules" }
+
+type cartItemPriceTierColumn struct {
+	PriceTier string `gorm:"size:100"`
+}
+
+func (cartItemPriceTierColumn) TableName() string { return "cart_items" }
+
+type orderItemPriceTierColumn struct {
+	PriceTier string `gorm:"size:100"`
+}
+
+func (orderItemPriceTierColumn) TableName() string { return "order_items" }
+
+func init() {
+	register(Migration{
+		Version: "20261017110000_create_price_rules",
+		Up: func(tx *gorm.DB) error {
+			err := tx.Migrator().CreateTable(&priceRuleTable{})
+			if err != nil {
+				return err
+			}
+
+			err = tx.Migrator().AddColumn(&cartItemPriceTierColumn{}, "PriceTier")
+			if err != nil {
+				return err
+			}
+			err = tx.Migrator().AddColumn(&orderItemPriceTierColumn{}, "PriceTier")
+			if err != nil {
+				return err
+			}
+
+			// Aturan bawaan menggantikan batas grosir yang sebelumnya tertulis di kode (qty > 2)
+			return tx.Create(&priceRuleTable{
+				ID:     uuid.New().String(),
+				Name:   "Harga grosir",
+				MinQty: 3,
+				Type:   "wholesale",
+				Status: 1,
+			}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&orderItemPriceTierColumn{}, "PriceTier"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&cartItemPriceTierColumn{}, "PriceTier"); err != nil {
+				return err
+			}
+
+			return tx.Migrator().DropTable(&priceRuleTable{})
+		},
+	})
+}
@@ -0,0 +1,204 @@
+package migrations
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gosimple/slug"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type customerGroupTable struct {
+	ID              string          `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	Code            string          `gorm:"size:50;not null;uniqueIndex"`
+	Name            string          `gorm:"size:100;not null"`
+	Description     string          `gorm:"size:255"`
+	PriceColumn     string          `gorm:"size:20;not null;default:'retail'"`
+	DiscountPercent decimal.Decimal `gorm:"type:decimal(5,2)"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       gorm.DeletedAt
+}
+
+func (customerGroupTable) TableName() string { return "customer_groups" }
+
+type roleCustomerGroupColumn struct {
+	CustomerGroupID string `gorm:"size:36;index"`
+}
+
+func (roleCustomerGroupColumn) TableName() string { return "roles" }
+
+type userCustomerGroupColumn struct {
+	CustomerGroupID string `gorm:"size:36;index"`
+}
+
+func (userCustomerGroupColumn) TableName() string { return "users" }
+
+type priceRuleCustomerGroupColumn struct {
+	CustomerGroupID string `gorm:"size:36;index"`
+}
+
+func (priceRuleCustomerGroupColumn) TableName() string { return "price_rules" }
+
+type priceRuleRoleColumn struct {
+	RoleID string `gorm:"size:36;index"`
+}
+
+func (priceRuleRoleColumn) TableName() string { return "price_rules" }
+
+// defaultCustomerGroups adalah kelompok pelanggan awal. Potongan persennya diatur admin.
+var defaultCustomerGroups = []customerGroupTable{
+	{Code: "eceran", Name: "Eceran", Description: "Pelanggan umum dengan harga eceran", PriceColumn: "retail"},
+	{Code: "reseller", Name: "Reseller", Description: "Reseller dengan harga grosir tanpa minimum qty", PriceColumn: "wholesale"},
+	{Code: "mitra-warung", Name: "Mitra Warung", Description: "Warung mitra dengan harga grosir tanpa minimum qty", PriceColumn: "wholesale"},
+}
+
+func init() {
+	register(Migration{
+		Version: "20261017120000_create_customer_groups",
+		Up: func(tx *gorm.DB) error {
+			err := tx.Migrator().CreateTable(&customerGroupTable{})
+			if err != nil {
+				return err
+			}
+
+			for _, group := range defaultCustomerGroups {
+				group.ID = uuid.New().String()
+				if err := tx.Create(&group).Error; err != nil {
+					return err
+				}
+			}
+
+			for _, column := range []interface{}{&roleCustomerGroupColumn{}, &userCustomerGroupColumn{}, &priceRuleCustomerGroupColumn{}} {
+				if err := tx.Migrator().AddColumn(column, "CustomerGroupID"); err != nil {
+					return err
+				}
+				if err := tx.Migrator().CreateIndex(column, "CustomerGroupID"); err != nil {
+					return err
+				}
+			}
+
+			// Role pelanggan umum memakai kelompok eceran
+			err = tx.Exec("UPDATE roles SET customer_group_id = (SELECT id FROM customer_groups WHERE code = ?) WHERE name = ?", "eceran", "Pengguna").Error
+			if err != nil {
+				return err
+			}
+
+			err = migrateRolePriceRules(tx)
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Migrator().DropIndex(&priceRuleRoleColumn{}, "RoleID"); err != nil {
+				return err
+			}
+
+			return tx.Exec("ALTER TABLE price_rules DROP COLUMN role_id").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			err := tx.Migrator().AddColumn(&priceRuleRoleColumn{}, "RoleID")
+			if err != nil {
+				return err
+			}
+			if err := tx.Migrator().CreateIndex(&priceRuleRoleColumn{}, "RoleID"); err != nil {
+				return err
+			}
+
+			err = restoreRolePriceRules(tx)
+			if err != nil {
+				return err
+			}
+
+			// DROP COLUMN langsung, karena DropColumn driver sqlite membuat ulang tabel tanpa index-nya
+			for _, column := range []interface{}{&priceRuleCustomerGroupColumn{}, &userCustomerGroupColumn{}, &roleCustomerGroupColumn{}} {
+				if err := tx.Migrator().DropIndex(column, "CustomerGroupID"); err != nil {
+					return err
+				}
+				table := column.(interface{ TableName() string }).TableName()
+				if err := tx.Exec("ALTER TABLE " + table + " DROP COLUMN customer_group_id").Error; err != nil {
+					return err
+				}
+			}
+
+			return tx.Migrator().DropTable(&customerGroupTable{})
+		},
+	})
+}
+
+// migrateRolePriceRules memindahkan aturan harga yang dibatasi role ke kelompok pelanggan. Setiap role
+// yang dipakai aturan mendapat kelompok dengan kode dari nama role (kelompok awal dipakai jika kodenya sama),
+// lalu kelompok itu menjadi kelompok bawaan role tersebut.
+func migrateRolePriceRules(tx *gorm.DB) error {
+	var roleIDs []string
+	err := tx.Table("price_rules").Where("role_id <> ''").Distinct("role_id").Pluck("role_id", &roleIDs).Error
+	if err != nil {
+		return err
+	}
+
+	for _, roleID := range roleIDs {
+		var roleNames []string
+		if err := tx.Table("roles").Where("id = ?", roleID).Pluck("name", &roleNames).Error; err != nil {
+			return err
+		}
+
+		// Role yang sudah tidak ada tidak dimiliki pengguna mana pun. ID-nya tetap disimpan agar aturan
+		// tersebut tetap tidak berlaku untuk siapa pun, bukan menjadi berlaku untuk semua pelanggan.
+		groupID := roleID
+		if len(roleNames) > 0 {
+			group := customerGroupTable{}
+			err := tx.Where("code = ?", slug.Make(roleNames[0])).First(&group).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				group = customerGroupTable{ID: uuid.New().String(), Code: slug.Make(roleNames[0]), Name: roleNames[0], PriceColumn: "retail"}
+				err = tx.Create(&group).Error
+			}
+			if err != nil {
+				return err
+			}
+			groupID = group.ID
+
+			err = tx.Table("roles").Where("id = ?", roleID).Update("customer_group_id", groupID).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		err := tx.Table("price_rules").Where("role_id = ?", roleID).Update("customer_group_id", groupID).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreRolePriceRules mengembalikan pembatas role pada aturan harga dari kelompok bawaan role.
+// Aturan untuk kelompok yang tidak terhubung ke role mana pun dinonaktifkan, karena tanpa pembatas
+// aturan itu akan berlaku untuk semua pelanggan.
+func restoreRolePriceRules(tx *gorm.DB) error {
+	var groupIDs []string
+	err := tx.Table("price_rules").Where("customer_group_id <> ''").Distinct("customer_group_id").Pluck("customer_group_id", &groupIDs).Error
+	if err != nil {
+		return err
+	}
+
+	for _, groupID := range groupIDs {
+		var roleIDs []string
+		if err := tx.Table("roles").Where("customer_group_id = ?", groupID).Order("name asc").Pluck("id", &roleIDs).Error; err != nil {
+			return err
+		}
+
+		rules := tx.Table("price_rules").Where("customer_group_id = ?", groupID)
+		if len(roleIDs) == 0 {
+			err = rules.Update("status", 0).Error
+		} else {
+			err = rules.Update("role_id", roleIDs[0]).Error
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
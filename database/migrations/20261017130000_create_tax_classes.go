@@ -0,0 +1,68 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type taxClassTable struct {
+	ID        string              `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	Code      string              `gorm:"size:50;not null;uniqueIndex"`
+	Name      string              `gorm:"size:100;not null"`
+	Rate      decimal.NullDecimal `gorm:"type:decimal(5,2)"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt
+}
+
+func (taxClassTable) TableName() string { return "tax_classes" }
+
+type productTaxClassColumn struct {
+	TaxClassID string `gorm:"size:36;index"`
+}
+
+func (productTaxClassColumn) TableName() string { return "products" }
+
+// defaultTaxClasses adalah kelas pajak awal. Rate kosong berarti mengikuti tarif PPN (TAX_PPN_RATE).
+var defaultTaxClasses = []taxClassTable{
+	{Code: "ppn", Name: "Barang kena PPN"},
+	{Code: "bebas-ppn", Name: "Dibebaskan dari PPN (kebutuhan pokok)", Rate: decimal.NullDecimal{Decimal: decimal.Zero, Valid: true}},
+}
+
+func init() {
+	register(Migration{
+		Version: "20261017130000_create_tax_classes",
+		Up: func(tx *gorm.DB) error {
+			err := tx.Migrator().CreateTable(&taxClassTable{})
+			if err != nil {
+				return err
+			}
+
+			for _, class := range defaultTaxClasses {
+				class.ID = uuid.New().String()
+				if err := tx.Create(&class).Error; err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Migrator().AddColumn(&productTaxClassColumn{}, "TaxClassID"); err != nil {
+				return err
+			}
+
+			return tx.Migrator().CreateIndex(&productTaxClassColumn{}, "TaxClassID")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&productTaxClassColumn{}, "TaxClassID"); err != nil {
+				return err
+			}
+			if err := tx.Exec("ALTER TABLE products DROP COLUMN tax_class_id").Error; err != nil {
+				return err
+			}
+
+			return tx.Migrator().DropTable(&taxClassTable{})
+		},
+	})
+}
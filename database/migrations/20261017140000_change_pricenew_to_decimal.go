@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type cartItemPricenewDecimalColumn struct {
+	Pricenew decimal.Decimal `gorm:"type:decimal(16,2)"`
+}
+
+func (cartItemPricenewDecimalColumn) TableName() string { return "cart_items" }
+
+type orderItemPricenewDecimalColumn struct {
+	Pricenew decimal.Decimal `gorm:"type:decimal(16,2)"`
+}
+
+func (orderItemPricenewDecimalColumn) TableName() string { return "order_items" }
+
+type cartItemPricenewIntColumn struct {
+	Pricenew int
+}
+
+func (cartItemPricenewIntColumn) TableName() string { return "cart_items" }
+
+type orderItemPricenewIntColumn struct {
+	Pricenew int
+}
+
+func (orderItemPricenewIntColumn) TableName() string { return "order_items" }
+
+func init() {
+	register(Migration{
+		Version: "20261017140000_change_pricenew_to_decimal",
+		Up: func(tx *gorm.DB) error {
+			return alterPricenew(tx, &cartItemPricenewDecimalColumn{}, &orderItemPricenewDecimalColumn{})
+		},
+		Down: func(tx *gorm.DB) error {
+			// Pecahan rupiah dibulatkan sebelum kolom kembali menjadi integer
+			for _, table := range []string{"cart_items", "order_items"} {
+				if err := tx.Exec("UPDATE " + table + " SET pricenew = ROUND(pricenew)").Error; err != nil {
+					return err
+				}
+			}
+
+			return alterPricenew(tx, &cartItemPricenewIntColumn{}, &orderItemPricenewIntColumn{})
+		},
+	})
+}
+
+// alterPricenew mengubah tipe kolom pricenew. SQLite dilewati: tipe kolomnya hanya afinitas dan nilai
+// desimal tetap tersimpan apa adanya, sedangkan AlterColumn driver sqlite membuat ulang tabel tanpa index-nya.
+func alterPricenew(tx *gorm.DB, columns ...interface{}) error {
+	if tx.Dialector.Name() == "sqlite" {
+		return nil
+	}
+
+	for _, column := range columns {
+		if err := tx.Migrator().AlterColumn(column, "Pricenew"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package migrations
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type voucherTable struct {
+	ID                string          `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	Code              string          `gorm:"size:50;not null;uniqueIndex"`
+	Name              string          `gorm:"size:100;not null"`
+	Type              string          `gorm:"size:20;not null"`
+	Value             decimal.Decimal `gorm:"type:decimal(16,2)"`
+	MinSpend          decimal.Decimal `gorm:"type:decimal(16,2)"`
+	MaxDiscount       decimal.Decimal `gorm:"type:decimal(16,2)"`
+	UsageLimit        int
+	UsageLimitPerUser int
+	ProductID         string `gorm:"size:36;index"`
+	CategoryID        string `gorm:"size:36;index"`
+	StartsAt          sql.NullTime
+	EndsAt            sql.NullTime
+	Status            int `gorm:"default:1"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	DeletedAt         gorm.DeletedAt
+}
+
+func (voucherTable) TableName() string { return "vouchers" }
+
+type voucherUsageTable struct {
+	ID             string          `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	VoucherID      string          `gorm:"size:36;index"`
+	UserID         string          `gorm:"size:36;index"`
+	OrderID        string          `gorm:"size:36;index"`
+	DiscountAmount decimal.Decimal `gorm:"type:decimal(16,2)"`
+	CreatedAt      time.Time
+}
+
+func (voucherUsageTable) TableName() string { return "voucher_usages" }
+
+type cartVoucherCodeColumn struct {
+	VoucherCode string `gorm:"size:50"`
+}
+
+func (cartVoucherCodeColumn) TableName() string { return "carts" }
+
+type orderVoucherCodeColumn struct {
+	VoucherCode string `gorm:"size:50"`
+}
+
+func (orderVoucherCodeColumn) TableName() string { return "orders" }
+
+func init() {
+	register(Migration{
+		Version: "20261017150000_create_vouchers",
+		Up: func(tx *gorm.DB) error {
+			err := tx.Migrator().CreateTable(&voucherTable{}, &voucherUsageTable{})
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Migrator().AddColumn(&cartVoucherCodeColumn{}, "VoucherCode"); err != nil {
+				return err
+			}
+
+			return tx.Migrator().AddColumn(&orderVoucherCodeColumn{}, "VoucherCode")
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, table := range []string{"orders", "carts"} {
+				if err := tx.Exec("ALTER TABLE " + table + " DROP COLUMN voucher_code").Error; err != nil {
+					return err
+				}
+			}
+
+			return tx.Migrator().DropTable(&voucherUsageTable{}, &voucherTable{})
+		},
+	})
+}
@@ -0,0 +1,75 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type flashSaleTable struct {
+	ID        string          `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	Name      string          `gorm:"size:100;not null"`
+	ProductID string          `gorm:"size:36;not null;index"`
+	UnitName  string          `gorm:"size:50;not null"`
+	Price     decimal.Decimal `gorm:"type:decimal(16,2)"`
+	Quota     int
+	StartsAt  time.Time
+	EndsAt    time.Time
+	Status    int `gorm:"default:1"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt
+}
+
+func (flashSaleTable) TableName() string { return "flash_sales" }
+
+type cartItemFlashSaleColumn struct {
+	FlashSaleID string `gorm:"size:36;index"`
+}
+
+func (cartItemFlashSaleColumn) TableName() string { return "cart_items" }
+
+type orderItemFlashSaleColumn struct {
+	FlashSaleID string `gorm:"size:36;index"`
+}
+
+func (orderItemFlashSaleColumn) TableName() string { return "order_items" }
+
+func init() {
+	register(Migration{
+		Version: "20261017160000_create_flash_sales",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().CreateTable(&flashSaleTable{}); err != nil {
+				return err
+			}
+
+			for _, column := range []interface{}{&cartItemFlashSaleColumn{}, &orderItemFlashSaleColumn{}} {
+				if err := tx.Migrator().AddColumn(column, "FlashSaleID"); err != nil {
+					return err
+				}
+				if err := tx.Migrator().CreateIndex(column, "FlashSaleID"); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			columns := map[string]interface{}{
+				"cart_items":  &cartItemFlashSaleColumn{},
+				"order_items": &orderItemFlashSaleColumn{},
+			}
+			for table, column := range columns {
+				if err := tx.Migrator().DropIndex(column, "FlashSaleID"); err != nil {
+					return err
+				}
+				if err := tx.Exec("ALTER TABLE " + table + " DROP COLUMN flash_sale_id").Error; err != nil {
+					return err
+				}
+			}
+
+			return tx.Migrator().DropTable(&flashSaleTable{})
+		},
+	})
+}
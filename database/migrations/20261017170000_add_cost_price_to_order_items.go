@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type orderItemCostPriceColumn struct {
+	CostPrice decimal.Decimal `gorm:"type:decimal(16,2)"`
+}
+
+func (orderItemCostPriceColumn) TableName() string { return "order_items" }
+
+func init() {
+	register(Migration{
+		Version: "20261017170000_add_cost_price_to_order_items",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&orderItemCostPriceColumn{}, "CostPrice"); err != nil {
+				return err
+			}
+
+			// Item order lama belum menyimpan harga pokok, diisi dengan harga pokok satuan saat migrasi dijalankan
+			return tx.Exec(`UPDATE order_items SET cost_price = COALESCE(
+				(SELECT product_units.cost_price FROM product_units WHERE product_units.id = order_items.product_unit_id), 0)`).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Exec("ALTER TABLE order_items DROP COLUMN cost_price").Error
+		},
+	})
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import "gorm.io/gorm"
+
+type cartUserColumn struct {
+	UserID string `gorm:"size:36;index"`
+}
+
+func (cartUserColumn) TableName() string { return "carts" }
+
+func init() {
+	register(Migration{
+		Version: "20261017180000_add_user_id_to_carts",
+		Up: func(tx *gorm.DB) error {
+			// Keranjang yang sudah ada tetap menjadi keranjang tamu sampai pemiliknya login lagi
+			if err := tx.Migrator().AddColumn(&cartUserColumn{}, "UserID"); err != nil {
+				return err
+			}
+
+			return tx.Migrator().CreateIndex(&cartUserColumn{}, "UserID")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&cartUserColumn{}, "UserID"); err != nil {
+				return err
+			}
+
+			return tx.Exec("ALTER TABLE carts DROP COLUMN user_id").Error
+		},
+	})
+}
@@ -0,0 +1,56 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type stockReservationTable struct {
+	ID          string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	OrderID     string `gorm:"size:36;not null;index"`
+	ProductID   string `gorm:"size:36;not null;index"`
+	Qty         int
+	Status      string `gorm:"size:20;not null;index"`
+	ExpiresAt   time.Time
+	CommittedAt *time.Time
+	ReleasedAt  *time.Time
+	Note        string `gorm:"size:255"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (stockReservationTable) TableName() string { return "stock_reservations" }
+
+type stockMovementTable struct {
+	ID            string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	ProductID     string `gorm:"size:36;not null;index"`
+	OrderID       string `gorm:"size:36;index"`
+	ReservationID string `gorm:"size:36;index"`
+	Qty           int
+	Type          string `gorm:"size:20;not null"`
+	Note          string `gorm:"size:255"`
+	CreatedAt     time.Time
+}
+
+func (stockMovementTable) TableName() string { return "stock_movements" }
+
+func init() {
+	register(Migration{
+		Version: "20261017190000_create_stock_reservations",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().CreateTable(&stockReservationTable{}); err != nil {
+				return err
+			}
+
+			return tx.Migrator().CreateTable(&stockMovementTable{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&stockMovementTable{}); err != nil {
+				return err
+			}
+
+			return tx.Migrator().DropTable(&stockReservationTable{})
+		},
+	})
+}
@@ -0,0 +1,54 @@
+package migrations
+
+import "gorm.io/gorm"
+
+func init() {
+	register(Migration{
+		Version: "20261017200000_unique_cart_user_id",
+		Up: func(tx *gorm.DB) error {
+			// Keranjang tamu memakai NULL agar tidak bentrok dengan indeks unik
+			if err := tx.Exec("UPDATE carts SET user_id = NULL WHERE user_id = ''").Error; err != nil {
+				return err
+			}
+
+			// Pelanggan yang terlanjur punya beberapa keranjang tetap memakai keranjang dengan item terbanyak,
+			// keranjang lainnya dilepas menjadi keranjang tamu
+			var carts []struct {
+				ID     string
+				UserID string
+			}
+			err := tx.Raw(`SELECT carts.id, carts.user_id FROM carts
+				LEFT JOIN cart_items ON cart_items.cart_id = carts.id
+				WHERE carts.user_id IS NOT NULL
+				GROUP BY carts.id, carts.user_id
+				ORDER BY carts.user_id, COUNT(cart_items.id) DESC, carts.id`).Scan(&carts).Error
+			if err != nil {
+				return err
+			}
+
+			kept := map[string]bool{}
+			for _, cart := range carts {
+				if !kept[cart.UserID] {
+					kept[cart.UserID] = true
+					continue
+				}
+				if err := tx.Exec("UPDATE carts SET user_id = NULL WHERE id = ?", cart.ID).Error; err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Migrator().DropIndex(&cartUserColumn{}, "UserID"); err != nil {
+				return err
+			}
+
+			return tx.Exec("CREATE UNIQUE INDEX idx_carts_user_id ON carts (user_id)").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&cartUserColumn{}, "idx_carts_user_id"); err != nil {
+				return err
+			}
+
+			return tx.Migrator().CreateIndex(&cartUserColumn{}, "UserID")
+		},
+	})
+}
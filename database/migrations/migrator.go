@@ -0,0 +1,233 @@
+package migrations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration adalah satu langkah perubahan skema yang dapat dijalankan dan dibatalkan
+type Migration struct {
+	Version string                  // Timestamp dan nama migrasi, misalnya 20240101000000_create_initial_tables
+	Up      func(tx *gorm.DB) error // Menerapkan perubahan skema
+	Down    func(tx *gorm.DB) error // Membatalkan perubahan skema
+}
+
+// SchemaMigration mencatat migrasi yang sudah dijalankan pada tabel schema_migrations
+type SchemaMigration struct {
+	Version   string `gorm:"size:255;not null;primary_key"`
+	Batch     int    `gorm:"not null;index"`
+	CreatedAt time.Time
+}
+
+// MigrationStatus adalah status sebuah migrasi untuk perintah db:migrate:status
+type MigrationStatus struct {
+	Version   string
+	Applied   bool
+	Batch     int
+	AppliedAt time.Time
+}
+
+var registered []Migration
+
+// register menambahkan migrasi ke daftar. Dipanggil dari init() setiap file migrasi.
+func register(migration Migration) {
+	registered = append(registered, migration)
+}
+
+// All mengembalikan semua migrasi yang terdaftar, diurutkan berdasarkan versi
+func All() []Migration {
+	migrations := make([]Migration, len(registered))
+	copy(migrations, registered)
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations
+}
+
+// Migrate menjalankan semua migrasi yang belum diterapkan dalam satu batch baru
+func Migrate(db *gorm.DB) error {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	batch := 1
+	for _, schemaMigration := range applied {
+		if schemaMigration.Batch >= batch {
+			batch = schemaMigration.Batch + 1
+		}
+	}
+
+	count := 0
+	for _, migration := range All() {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		fmt.Printf("Migrating: %s\n", migration.Version)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+
+			return tx.Create(&SchemaMigration{Version: migration.Version, Batch: batch}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migrasi %s gagal: %w", migration.Version, err)
+		}
+		fmt.Printf("Migrated:  %s\n", migration.Version)
+		count++
+	}
+
+	if count == 0 {
+		fmt.Println("Nothing to migrate.")
+	}
+
+	return nil
+}
+
+// Rollback membatalkan migrasi terakhir. Jika steps bernilai 0, seluruh batch terakhir dibatalkan,
+// selain itu sejumlah steps migrasi terakhir dibatalkan.
+func Rollback(db *gorm.DB, steps int) error {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	migrations := map[string]Migration{}
+	for _, migration := range All() {
+		migrations[migration.Version] = migration
+	}
+
+	var targets []SchemaMigration
+	for _, schemaMigration := range applied {
+		targets = append(targets, schemaMigration)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Batch != targets[j].Batch {
+			return targets[i].Batch > targets[j].Batch
+		}
+		return targets[i].Version > targets[j].Version
+	})
+
+	if len(targets) == 0 {
+		fmt.Println("Nothing to rollback.")
+		return nil
+	}
+
+	lastBatch := targets[0].Batch
+	for i, target := range targets {
+		if steps > 0 && i >= steps {
+			break
+		}
+		if steps == 0 && target.Batch != lastBatch {
+			break
+		}
+
+		migration, ok := migrations[target.Version]
+		if !ok {
+			return fmt.Errorf("migrasi %s tidak ditemukan di kode", target.Version)
+		}
+
+		fmt.Printf("Rolling back: %s\n", migration.Version)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+
+			return tx.Delete(&SchemaMigration{}, "version = ?", migration.Version).Error
+		})
+		if err != nil {
+			return fmt.Errorf("rollback %s gagal: %w", migration.Version, err)
+		}
+		fmt.Printf("Rolled back:  %s\n", migration.Version)
+	}
+
+	return nil
+}
+
+// Status mengembalikan status setiap migrasi yang terdaftar
+func Status(db *gorm.DB) ([]MigrationStatus, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, migration := range All() {
+		status := MigrationStatus{Version: migration.Version}
+		if schemaMigration, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.Batch = schemaMigration.Batch
+			status.AppliedAt = schemaMigration.CreatedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// MakeMigration membuat file migrasi kosong di direktori dir dan mengembalikan path file tersebut
+func MakeMigration(dir string, name string) (string, error) {
+	name = strings.Trim(regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", fmt.Errorf("nama migrasi tidak boleh kosong")
+	}
+
+	version := time.Now().Format("20060102150405") + "_" + name
+	path := filepath.Join(dir, version+".go")
+
+	content := fmt.Sprintf(`package migrations
+
+import "gorm.io/gorm"
+
+func init() {
+	register(Migration{
+		Version: %q,
+		Up: func(tx *gorm.DB) error {
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	})
+}
+`, version)
+
+	err := os.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// appliedMigrations memastikan tabel schema_migrations ada lalu mengembalikan isinya per versi
+func appliedMigrations(db *gorm.DB) (map[string]SchemaMigration, error) {
+	err := db.AutoMigrate(&SchemaMigration{})
+	if err != nil {
+		return nil, err
+	}
+
+	var schemaMigrations []SchemaMigration
+	err = db.Find(&schemaMigrations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	applied := map[string]SchemaMigration{}
+	for _, schemaMigration := range schemaMigrations {
+		applied[schemaMigration.Version] = schemaMigration
+	}
+
+	return applied, nil
+}